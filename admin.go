@@ -0,0 +1,187 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+var pauseLock sync.RWMutex
+
+type adminReq struct {
+	TeamID  string `json:"team_id"`
+	Channel string `json:"channel"`
+	// Value 覆盖的本地状态，格式同 getValue: "比赛状态|主队比分|客队比分"
+	Value string `json:"value"`
+}
+
+func isPushPaused() bool {
+	pauseLock.RLock()
+	defer pauseLock.RUnlock()
+	return state.pushPaused
+}
+
+func setPushPaused(paused bool) {
+	pauseLock.Lock()
+	defer pauseLock.Unlock()
+	state.pushPaused = paused
+}
+
+// withAdminAuth 校验 Authorization: Bearer <admin_token>，未配置 admin_token 时管理接口不可用
+func withAdminAuth(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if conf.AdminToken == "" {
+			http.NotFound(w, r)
+			return
+		}
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(conf.AdminToken)) != 1 {
+			writeJsonErr(w, http.StatusUnauthorized, errors.New("admin token 无效"))
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// handleAdminRefresh POST /api/admin/refresh 忽略检查时间，立即拉取并推送一次
+func handleAdminRefresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	log.Printf("管理接口：立即刷新")
+	GoWithRecovery(func() {
+		refreshData(workCtx, true)
+	})
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "refreshing"})
+}
+
+// handleAdminResend POST /api/admin/resend 把某场比赛的最新卡片重新推送到指定渠道
+func handleAdminResend(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	req := &adminReq{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeJsonErr(w, http.StatusBadRequest, err)
+		return
+	}
+	if isPushPaused() {
+		writeJsonErr(w, http.StatusConflict, errors.New("推送已暂停"))
+		return
+	}
+
+	match := findMatch(getLatestMatches(), req.TeamID)
+	if match == nil {
+		writeJsonErr(w, http.StatusNotFound, fmt.Errorf("比赛[%s]不存在", req.TeamID))
+		return
+	}
+	var channel *ChannelConfig
+	for _, c := range conf.getChannels() {
+		if c.Name == req.Channel {
+			channel = c
+		}
+	}
+	if channel == nil {
+		writeJsonErr(w, http.StatusNotFound, fmt.Errorf("渠道[%s]不存在", req.Channel))
+		return
+	}
+
+	var msg interface{} = makePush(match, channel.location())
+	if !channel.isWeCom() {
+		msg = makeEventPayload(&RaceEvent{Match: match})
+	}
+	msgByte, _ := json.Marshal(msg)
+	if err := postWithRetry(r.Context(), channel.Webhook, msgByte); err != nil {
+		writeJsonErr(w, http.StatusBadGateway, err)
+		return
+	}
+	log.Printf("管理接口：重新推送[%s]：[%s]%s->%s", channel.Name, match.ID, match.Host.Name, match.Guest.Name)
+	writeJson(w, map[string]string{"status": "sent"})
+}
+
+// handleAdminState GET/PUT/DELETE /api/admin/state 查看、覆盖或清除比赛的本地状态
+// 清除后下次拉取会把该比赛当作新变更重新推送
+func handleAdminState(w http.ResponseWriter, r *http.Request) {
+	// 先读取请求体再加锁，避免慢客户端阻塞拉取
+	req := &adminReq{}
+	if r.Method != http.MethodGet {
+		err := json.NewDecoder(r.Body).Decode(req)
+		if err == nil && req.TeamID == "" {
+			err = errors.New("team_id 不能为空")
+		}
+		if err != nil {
+			writeJsonErr(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	refreshLock.Lock()
+	defer refreshLock.Unlock()
+
+	if r.Method != http.MethodGet {
+		if state.localMap == nil {
+			writeJsonErr(w, http.StatusBadRequest, errors.New("本地状态尚未初始化"))
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPut:
+			if parseValue(req.Value) == nil {
+				writeJsonErr(w, http.StatusBadRequest, errors.New("value 格式应为 比赛状态|主队比分|客队比分"))
+				return
+			}
+			state.localMap[req.TeamID] = req.Value
+			log.Printf("管理接口：覆盖本地状态[%s]=%s", req.TeamID, req.Value)
+		case http.MethodDelete:
+			delete(state.localMap, req.TeamID)
+			log.Printf("管理接口：清除本地状态[%s]", req.TeamID)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+	}
+
+	result := make(map[string]string, len(state.localMap))
+	for key, value := range state.localMap {
+		result[key] = value
+	}
+	writeJson(w, result)
+}
+
+// handleAdminPause POST /api/admin/pause、/api/admin/resume 暂停、恢复推送，GET 查看当前状态。
+// 恢复后立即补发暂停期间积压的消息(免打扰中的渠道等免打扰结束)
+func handleAdminPause(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		paused := r.URL.Path == "/api/admin/pause"
+		setPushPaused(paused)
+		log.Printf("管理接口：暂停推送[%t]", paused)
+		if !paused {
+			GoWithRecovery(func() {
+				flushQuietQueues(workCtx)
+			})
+		}
+	} else if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeJson(w, map[string]bool{"paused": isPushPaused()})
+}
+
+// findMatch 按 team_id(比赛 id)查找比赛
+func findMatch(matches []*Match, id string) *Match {
+	for _, match := range matches {
+		if match.ID == id {
+			return match
+		}
+	}
+	return nil
+}
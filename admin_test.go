@@ -0,0 +1,123 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// useAdminConf 测试期间使用配置了 admin_token 的默认配置
+func useAdminConf(t *testing.T, token string) {
+	prev := conf
+	conf = defaultConfig()
+	conf.AdminToken = token
+	t.Cleanup(func() {
+		conf = prev
+	})
+}
+
+func TestAdminAuth(t *testing.T) {
+	cases := []struct {
+		name   string
+		token  string
+		header string
+		code   int
+	}{
+		{name: "未配置 admin_token", header: "Bearer secret", code: http.StatusNotFound},
+		{name: "缺少 token", token: "secret", code: http.StatusUnauthorized},
+		{name: "token 错误", token: "secret", header: "Bearer wrong", code: http.StatusUnauthorized},
+		{name: "token 正确", token: "secret", header: "Bearer secret", code: http.StatusOK},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			useAdminConf(t, c.token)
+			called := false
+			handler := withAdminAuth(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				writeJson(w, map[string]string{"status": "ok"})
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/state", nil)
+			if c.header != "" {
+				req.Header.Set("Authorization", c.header)
+			}
+			recorder := httptest.NewRecorder()
+			handler(recorder, req)
+			if recorder.Code != c.code || called != (c.code == http.StatusOK) {
+				t.Fatalf("返回 %d，handler 是否调用[%t]，期望 %d", recorder.Code, called, c.code)
+			}
+		})
+	}
+}
+
+func TestHandleAdminState(t *testing.T) {
+	useFreshState(t)
+
+	request := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/admin/state", strings.NewReader(body))
+		recorder := httptest.NewRecorder()
+		handleAdminState(recorder, req)
+		return recorder
+	}
+
+	if resp := request(http.MethodPut, `{"team_id":"1","value":"3|0|2"}`); resp.Code != http.StatusBadRequest {
+		t.Errorf("未初始化时覆盖返回 %d", resp.Code)
+	}
+
+	state.localMap = map[string]string{"1": "3|0|2", "2": "1|-|-"}
+	cases := []struct {
+		name   string
+		method string
+		body   string
+		code   int
+		want   map[string]string
+	}{
+		{name: "缺少 team_id", method: http.MethodPut, body: `{"value":"3|0|2"}`, code: http.StatusBadRequest},
+		{name: "格式错误", method: http.MethodPut, body: `{"team_id":"1","value":"3"}`, code: http.StatusBadRequest},
+		{name: "覆盖", method: http.MethodPut, body: `{"team_id":"1","value":"3|1|2"}`, code: http.StatusOK,
+			want: map[string]string{"1": "3|1|2", "2": "1|-|-"}},
+		{name: "清除", method: http.MethodDelete, body: `{"team_id":"2"}`, code: http.StatusOK,
+			want: map[string]string{"1": "3|1|2"}},
+		{name: "方法不支持", method: http.MethodPost, body: `{"team_id":"2"}`, code: http.StatusMethodNotAllowed},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			resp := request(c.method, c.body)
+			if resp.Code != c.code {
+				t.Fatalf("返回 %d，期望 %d: %s", resp.Code, c.code, resp.Body.String())
+			}
+			if c.want != nil && len(state.localMap) != len(c.want) {
+				t.Fatalf("本地状态为 %v，期望 %v", state.localMap, c.want)
+			}
+			for key, value := range c.want {
+				if state.localMap[key] != value {
+					t.Fatalf("本地状态为 %v，期望 %v", state.localMap, c.want)
+				}
+			}
+		})
+	}
+}
+
+// TestHandleAdminPause 暂停后 GET 返回暂停状态，未知方法返回 405
+func TestHandleAdminPause(t *testing.T) {
+	useFreshState(t)
+
+	recorder := httptest.NewRecorder()
+	handleAdminPause(recorder, httptest.NewRequest(http.MethodPost, "/api/admin/pause", nil))
+	if recorder.Code != http.StatusOK || !isPushPaused() {
+		t.Fatalf("暂停返回 %d，是否暂停[%t]", recorder.Code, isPushPaused())
+	}
+
+	recorder = httptest.NewRecorder()
+	handleAdminPause(recorder, httptest.NewRequest(http.MethodGet, "/api/admin/pause", nil))
+	if body := strings.TrimSpace(recorder.Body.String()); body != `{"paused":true}` {
+		t.Errorf("GET 返回 %s", body)
+	}
+
+	recorder = httptest.NewRecorder()
+	handleAdminPause(recorder, httptest.NewRequest(http.MethodDelete, "/api/admin/pause", nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE 返回 %d", recorder.Code)
+	}
+}
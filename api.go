@@ -0,0 +1,142 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// standingRowResp 积分榜接口的一行，补充净胜球
+type standingRowResp struct {
+	*StandingRow
+	Rank     int `json:"rank"`
+	GoalDiff int `json:"goal_diff"`
+}
+
+type standingsResp struct {
+	Group string             `json:"group"`
+	Rows  []*standingRowResp `json:"rows"`
+}
+
+// handleMatches GET /api/matches 全部比赛；GET /api/matches/{id} 单场比赛
+func handleMatches(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	matches := getLatestMatches()
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/matches"), "/")
+	if id == "" {
+		writeJsonWithETag(w, r, matches)
+		return
+	}
+
+	for _, match := range matches {
+		if match.ID == id {
+			writeJsonWithETag(w, r, match)
+			return
+		}
+	}
+	writeJsonErr(w, http.StatusNotFound, fmt.Errorf("比赛[%s]不存在", id))
+}
+
+// handleGroups GET /api/groups/{name}/standings 小组积分榜
+func handleGroups(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/groups"), "/"), "/")
+	if len(parts) != 2 || parts[1] != "standings" {
+		writeJsonErr(w, http.StatusNotFound, errors.New("接口不存在"))
+		return
+	}
+
+	group := strings.ToUpper(parts[0])
+	rows, ok := computeGroupStandings(getLatestMatches())[group]
+	if !ok {
+		writeJsonErr(w, http.StatusNotFound, fmt.Errorf("小组[%s]不存在", group))
+		return
+	}
+
+	writeJsonWithETag(w, r, makeStandingsResp(group, rows))
+}
+
+func makeStandingsResp(group string, rows []*StandingRow) *standingsResp {
+	resp := &standingsResp{Group: group, Rows: make([]*standingRowResp, 0, len(rows))}
+	for i, row := range rows {
+		resp.Rows = append(resp.Rows, &standingRowResp{StandingRow: row, Rank: i + 1, GoalDiff: row.GoalDiff()})
+	}
+	return resp
+}
+
+// handleToday GET /api/today 今日比赛，可用 ?tz=Asia/Tokyo 指定"今日"的时区
+func handleToday(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	loc := TournamentLocation
+	if tz := r.URL.Query().Get("tz"); tz != "" {
+		var err error
+		loc, err = time.LoadLocation(tz)
+		if err != nil {
+			writeJsonErr(w, http.StatusBadRequest, fmt.Errorf("时区[%s]无效", tz))
+			return
+		}
+	}
+
+	today := clock.Now().In(loc).Format("2006-01-02")
+	matches := getLatestMatches()
+	result := make([]*Match, 0)
+	for _, match := range matches {
+		if match.Kickoff.In(loc).Format("2006-01-02") == today {
+			result = append(result, match)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Kickoff.Before(result[j].Kickoff)
+	})
+	writeJsonWithETag(w, r, result)
+}
+
+// writeJsonWithETag 以响应内容的摘要作为 ETag，内容未变时返回 304
+func writeJsonWithETag(w http.ResponseWriter, r *http.Request, data interface{}) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		writeJsonErr(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	sum := sha1.Sum(body)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if matchETag(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_, _ = w.Write(append(body, '\n'))
+}
+
+// matchETag If-None-Match 可以是逗号分隔的多个 ETag 或 *
+func matchETag(header, etag string) bool {
+	for _, item := range strings.Split(header, ",") {
+		item = strings.TrimSpace(item)
+		if item == "*" || strings.TrimPrefix(item, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
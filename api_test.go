@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// apiTestMatches 设置 A 组两场比赛作为最近一次拉取的数据
+func apiTestMatches(t *testing.T) {
+	useFreshState(t)
+	matches := make([]*Match, 0, 2)
+	for _, race := range []*FifaScheduleList{
+		testRace("1", "2022-11-21 00:00:00", "3", "1", "3", "0", "2"),
+		testRace("2", "2022-11-22 00:00:00", "4", "2", "1", "-", "-"),
+	} {
+		match, err := newMatch(race)
+		if err != nil {
+			t.Fatal(err)
+		}
+		matches = append(matches, match)
+	}
+	setLatestMatches(matches)
+}
+
+func serveAPI(handler http.HandlerFunc, method, target string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	recorder := httptest.NewRecorder()
+	handler(recorder, req)
+	return recorder
+}
+
+func TestHandleMatches(t *testing.T) {
+	apiTestMatches(t)
+
+	cases := []struct {
+		name   string
+		method string
+		target string
+		code   int
+	}{
+		{name: "全部比赛", method: http.MethodGet, target: "/api/matches", code: http.StatusOK},
+		{name: "单场比赛", method: http.MethodGet, target: "/api/matches/2", code: http.StatusOK},
+		{name: "比赛不存在", method: http.MethodGet, target: "/api/matches/99", code: http.StatusNotFound},
+		{name: "方法不支持", method: http.MethodPost, target: "/api/matches", code: http.StatusMethodNotAllowed},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if resp := serveAPI(handleMatches, c.method, c.target, nil); resp.Code != c.code {
+				t.Fatalf("返回 %d，期望 %d: %s", resp.Code, c.code, resp.Body.String())
+			}
+		})
+	}
+
+	match := &Match{}
+	if err := json.Unmarshal(serveAPI(handleMatches, http.MethodGet, "/api/matches/2", nil).Body.Bytes(), match); err != nil {
+		t.Fatal(err)
+	}
+	if match.ID != "2" || match.Host.Name != "塞内加尔" {
+		t.Errorf("返回的比赛为 %+v", match)
+	}
+}
+
+// TestETag 内容未变时返回 304，比分变化后 ETag 随之变化
+func TestETag(t *testing.T) {
+	apiTestMatches(t)
+
+	first := serveAPI(handleMatches, http.MethodGet, "/api/matches", nil)
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("首次请求返回 %d，ETag[%s]", first.Code, etag)
+	}
+
+	for _, header := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+		resp := serveAPI(handleMatches, http.MethodGet, "/api/matches", http.Header{"If-None-Match": {header}})
+		if resp.Code != http.StatusNotModified || resp.Body.Len() != 0 {
+			t.Errorf("If-None-Match[%s] 返回 %d", header, resp.Code)
+		}
+	}
+
+	matches := getLatestMatches()
+	changed := *matches[0]
+	changed.Score = &MatchScore{Host: 1, Guest: 2, WinnerTeamID: "1"}
+	setLatestMatches([]*Match{&changed, matches[1]})
+	resp := serveAPI(handleMatches, http.MethodGet, "/api/matches", http.Header{"If-None-Match": {etag}})
+	if resp.Code != http.StatusOK || resp.Header().Get("ETag") == etag {
+		t.Errorf("比分变化后返回 %d，ETag[%s]", resp.Code, resp.Header().Get("ETag"))
+	}
+}
+
+func TestHandleGroups(t *testing.T) {
+	apiTestMatches(t)
+
+	resp := serveAPI(handleGroups, http.MethodGet, "/api/groups/a/standings", nil)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("返回 %d: %s", resp.Code, resp.Body.String())
+	}
+	standings := &standingsResp{}
+	if err := json.Unmarshal(resp.Body.Bytes(), standings); err != nil {
+		t.Fatal(err)
+	}
+	if standings.Group != "A" || len(standings.Rows) == 0 || standings.Rows[0].TeamID != "1" ||
+		standings.Rows[0].Rank != 1 || standings.Rows[0].GoalDiff != 2 {
+		t.Errorf("积分榜为 %s", resp.Body.String())
+	}
+
+	for _, target := range []string{"/api/groups/Z/standings", "/api/groups/A/matches", "/api/groups/A"} {
+		if resp = serveAPI(handleGroups, http.MethodGet, target, nil); resp.Code != http.StatusNotFound {
+			t.Errorf("%s 返回 %d", target, resp.Code)
+		}
+	}
+}
+
+func TestHandleToday(t *testing.T) {
+	apiTestMatches(t)
+	setClock(NewFakeClock(tournamentTime(t, "2022-11-21 23:30:00")))
+	defer setClock(realClock{})
+
+	resp := serveAPI(handleToday, http.MethodGet, "/api/today", nil)
+	matches := make([]*Match, 0)
+	if err := json.Unmarshal(resp.Body.Bytes(), &matches); err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].ID != "1" {
+		t.Errorf("今日比赛为 %s", resp.Body.String())
+	}
+
+	// 东京已是 11 月 22 日，第 2 场在当地为 11 月 22 日 01:00
+	resp = serveAPI(handleToday, http.MethodGet, "/api/today?tz=Asia/Tokyo", nil)
+	matches = matches[:0]
+	if err := json.Unmarshal(resp.Body.Bytes(), &matches); err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].ID != "2" {
+		t.Errorf("东京今日比赛为 %s", resp.Body.String())
+	}
+
+	if resp = serveAPI(handleToday, http.MethodGet, "/api/today?tz=Mars/Base", nil); resp.Code != http.StatusBadRequest {
+		t.Errorf("无效时区返回 %d", resp.Code)
+	}
+}
@@ -0,0 +1,475 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// BracketSlot 淘汰赛对阵中的一方，TeamID 为空表示还未确定
+type BracketSlot struct {
+	Source   string `json:"source"` // 来源，如 "1A"(A组第一)、"W49"(第49场胜者)、"L61"(第61场负者)
+	TeamID   string `json:"team_id"`
+	TeamName string `json:"team_name"`
+	LogoURL  string `json:"logo_url"`
+}
+
+// Label 已确定时显示队名，否则显示来源
+func (s *BracketSlot) Label() string {
+	if s.TeamName != "" {
+		return s.TeamName
+	}
+	if len(s.Source) < 2 {
+		return s.Source
+	}
+	switch s.Source[0] {
+	case 'W':
+		return fmt.Sprintf("第%s场胜者", s.Source[1:])
+	case 'L':
+		return fmt.Sprintf("第%s场负者", s.Source[1:])
+	}
+	return fmt.Sprintf("%s组第%s", s.Source[1:], s.Source[:1])
+}
+
+// BracketMatch 淘汰赛的一场比赛，No 为官方比赛编号
+type BracketMatch struct {
+	No         int          `json:"no"`
+	MatchType  string       `json:"match_type"`
+	Host       *BracketSlot `json:"host"`
+	Guest      *BracketSlot `json:"guest"`
+	HostScore  string       `json:"host_score"`
+	GuestScore string       `json:"guest_score"`
+	WinnerID   string       `json:"winner_id"`
+	Detail     string       `json:"detail"` // 加时、点球说明
+	Race       *Match       `json:"-"`
+}
+
+// Bracket 淘汰赛对阵图，Rounds 依次为 1/8决赛、1/4决赛、半决赛、决赛，ThirdPlace 为季军赛
+type Bracket struct {
+	Rounds     [][]*BracketMatch `json:"rounds"`
+	ThirdPlace *BracketMatch     `json:"third_place"`
+}
+
+// bracketLayout 2022 世界杯淘汰赛对阵，比赛编号按开赛时间先后排列
+var bracketLayout = []struct {
+	No          int
+	MatchType   string
+	Host, Guest string
+}{
+	{49, "2", "1A", "2B"}, {50, "2", "1C", "2D"}, {51, "2", "1D", "2C"}, {52, "2", "1B", "2A"},
+	{53, "2", "1E", "2F"}, {54, "2", "1G", "2H"}, {55, "2", "1F", "2E"}, {56, "2", "1H", "2G"},
+	{57, "3", "W53", "W54"}, {58, "3", "W49", "W50"}, {59, "3", "W55", "W56"}, {60, "3", "W51", "W52"},
+	{61, "4", "W57", "W58"}, {62, "4", "W59", "W60"},
+	{63, "5", "L61", "L62"},
+	{64, "6", "W61", "W62"},
+}
+
+// bracketOrder 对阵图中每轮自上而下的比赛顺序，保证相邻两场的胜者在下一轮相遇
+var bracketOrder = [][]int{
+	{49, 50, 53, 54, 51, 52, 55, 56},
+	{58, 57, 60, 59},
+	{61, 62},
+	{64},
+}
+
+// buildBracket 根据小组排名与淘汰赛结果生成对阵图
+func buildBracket(races []*Match) (result *Bracket) {
+	// 小组全部完赛后才确定前两名
+	seeds := make(map[string]*BracketSlot)
+	standings := standingsFromMatches(races, false)
+	for group, rows := range standings {
+		if !groupFinished(races, group) {
+			continue
+		}
+		for i := 0; i < len(rows) && i < 2; i++ {
+			seeds[fmt.Sprintf("%d%s", i+1, group)] = &BracketSlot{TeamID: rows[i].TeamID, TeamName: rows[i].TeamName}
+		}
+	}
+	for _, race := range races {
+		for _, seed := range seeds {
+			if seed.LogoURL != "" {
+				continue
+			}
+			if race.Host.ID == seed.TeamID {
+				seed.LogoURL = race.Host.LogoURL
+			} else if race.Guest.ID == seed.TeamID {
+				seed.LogoURL = race.Guest.LogoURL
+			}
+		}
+	}
+
+	// 聚合数据的 team_id 即官方比赛编号；其他数据源按开赛时间先后对应
+	raceMap := make(map[int]*Match)
+	layoutType := make(map[int]string)
+	for _, layout := range bracketLayout {
+		layoutType[layout.No] = layout.MatchType
+	}
+	raceByType := make(map[string][]*Match)
+	for _, race := range races {
+		if race.MatchType == GroupMatchType || race.MatchType == "" {
+			continue
+		}
+		if no, err := strconv.Atoi(race.ID); err == nil && layoutType[no] == race.MatchType && raceMap[no] == nil {
+			raceMap[no] = race
+			continue
+		}
+		raceByType[race.MatchType] = append(raceByType[race.MatchType], race)
+	}
+	for _, list := range raceByType {
+		sort.SliceStable(list, func(i, j int) bool {
+			if !list[i].Kickoff.Equal(list[j].Kickoff) {
+				return list[i].Kickoff.Before(list[j].Kickoff)
+			}
+			return list[i].ID < list[j].ID
+		})
+	}
+	for _, layout := range bracketLayout {
+		if list := raceByType[layout.MatchType]; raceMap[layout.No] == nil && len(list) > 0 {
+			raceMap[layout.No] = list[0]
+			raceByType[layout.MatchType] = list[1:]
+		}
+	}
+
+	// bracketLayout 按编号排列，前一轮总是先于后一轮处理
+	matchMap := make(map[int]*BracketMatch)
+	for _, layout := range bracketLayout {
+		match := &BracketMatch{
+			No:        layout.No,
+			MatchType: layout.MatchType,
+			Host:      resolveSlot(layout.Host, seeds, matchMap),
+			Guest:     resolveSlot(layout.Guest, seeds, matchMap),
+			Race:      raceMap[layout.No],
+		}
+		if match.Race != nil {
+			fillFromRace(match)
+			// 数据源没有点球比分时，从下一轮的对阵推断晋级球队
+			if match.WinnerID == "" && match.Race.Status == StatusFinished {
+				match.WinnerID = advancedTeamID(match, raceMap)
+			}
+		}
+		matchMap[layout.No] = match
+	}
+
+	result = &Bracket{ThirdPlace: matchMap[63]}
+	for _, round := range bracketOrder {
+		list := make([]*BracketMatch, 0, len(round))
+		for _, no := range round {
+			list = append(list, matchMap[no])
+		}
+		result.Rounds = append(result.Rounds, list)
+	}
+	return
+}
+
+// resolveSlot 解析 "1A"、"W49"、"L61" 形式的来源
+func resolveSlot(source string, seeds map[string]*BracketSlot, matchMap map[int]*BracketMatch) *BracketSlot {
+	slot := &BracketSlot{Source: source}
+	if seed, ok := seeds[source]; ok {
+		slot.TeamID, slot.TeamName, slot.LogoURL = seed.TeamID, seed.TeamName, seed.LogoURL
+		return slot
+	}
+
+	no, err := strconv.Atoi(source[1:])
+	if err != nil {
+		return slot
+	}
+	prev, ok := matchMap[no]
+	if !ok || prev.WinnerID == "" {
+		return slot
+	}
+
+	winner, loser := prev.Host, prev.Guest
+	if prev.Guest.TeamID == prev.WinnerID {
+		winner, loser = prev.Guest, prev.Host
+	}
+	from := winner
+	if source[0] == 'L' {
+		from = loser
+	}
+	slot.TeamID, slot.TeamName, slot.LogoURL = from.TeamID, from.TeamName, from.LogoURL
+	return slot
+}
+
+// fillFromRace 用数据源中的对阵和比分覆盖推算结果
+func fillFromRace(match *BracketMatch) {
+	race := match.Race
+	if knownTeamID(race.Host.ID) {
+		match.Host.TeamID, match.Host.TeamName, match.Host.LogoURL = race.Host.ID, race.Host.Name, race.Host.LogoURL
+	}
+	if knownTeamID(race.Guest.ID) {
+		match.Guest.TeamID, match.Guest.TeamName, match.Guest.LogoURL = race.Guest.ID, race.Guest.Name, race.Guest.LogoURL
+	}
+	if race.Status == StatusNotStarted || race.Score == nil {
+		return
+	}
+
+	score := race.Score
+	match.HostScore, match.GuestScore = race.scoreText()
+	match.Detail = score.Detail()
+	if knownTeamID(score.WinnerTeamID) {
+		match.WinnerID = score.WinnerTeamID
+	}
+}
+
+// knownTeamID 未确定的对阵 team_id 为 "0"
+func knownTeamID(teamID string) bool {
+	return teamID != "" && teamID != "0"
+}
+
+// advancedTeamID 下一轮对阵中出现了哪一方，即为晋级球队
+func advancedTeamID(match *BracketMatch, raceMap map[int]*Match) string {
+	source := fmt.Sprintf("W%d", match.No)
+	for _, layout := range bracketLayout {
+		if layout.Host != source && layout.Guest != source {
+			continue
+		}
+		next, ok := raceMap[layout.No]
+		if !ok {
+			return ""
+		}
+		for _, teamID := range []string{match.Host.TeamID, match.Guest.TeamID} {
+			if knownTeamID(teamID) && next.hasTeam(teamID) {
+				return teamID
+			}
+		}
+	}
+	return ""
+}
+
+// groupFinished 小组赛是否全部完赛
+func groupFinished(races []*Match, group string) bool {
+	count := 0
+	for _, race := range races {
+		if race.MatchType != GroupMatchType || race.Group != group {
+			continue
+		}
+		if race.Status != StatusFinished {
+			return false
+		}
+		count++
+	}
+	return count > 0
+}
+
+// Text 对阵图的 markdown 文本
+func (b *Bracket) Text() string {
+	roundNames := []string{"1/8决赛", "1/4决赛", "半决赛", "决赛"}
+	lines := []string{"**🏆 淘汰赛对阵图**"}
+	for i, round := range b.Rounds {
+		lines = append(lines, "", fmt.Sprintf("**%s**", roundNames[i]))
+		for _, match := range round {
+			lines = append(lines, "> "+match.Text())
+		}
+		if i == len(b.Rounds)-2 && b.ThirdPlace != nil {
+			lines = append(lines, "", "**季军赛**", "> "+b.ThirdPlace.Text())
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Text 单场对阵的文本，晋级球队加粗
+func (m *BracketMatch) Text() string {
+	host, guest := m.Host.Label(), m.Guest.Label()
+	if m.WinnerID != "" && m.WinnerID == m.Host.TeamID {
+		host = "**" + host + "**"
+	} else if m.WinnerID != "" && m.WinnerID == m.Guest.TeamID {
+		guest = "**" + guest + "**"
+	}
+	if m.HostScore == "" {
+		return fmt.Sprintf("%s vs %s", host, guest)
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s %s : %s %s %s", host, m.HostScore, m.GuestScore, guest, m.Detail))
+}
+
+const (
+	bracketColWidth = 300
+	bracketBoxWidth = 260
+	bracketBoxRow   = 32
+	bracketSlotH    = 96
+	bracketPadding  = 40
+)
+
+// bracketBoxes 计算每场比赛方框左上角的位置，后一轮的方框位于前一轮两场之间
+func (b *Bracket) bracketBoxes() (width, height int, boxes map[*BracketMatch]image.Point) {
+	boxes = make(map[*BracketMatch]image.Point)
+	height = bracketPadding*2 + len(b.Rounds[0])*bracketSlotH + bracketSlotH
+	width = bracketPadding*2 + len(b.Rounds)*bracketColWidth
+
+	centers := make([]int, 0)
+	for i := range b.Rounds[0] {
+		centers = append(centers, bracketPadding+i*bracketSlotH+bracketSlotH/2)
+	}
+	for col, round := range b.Rounds {
+		if col > 0 {
+			next := make([]int, 0, len(round))
+			for i := 0; i+1 < len(centers); i += 2 {
+				next = append(next, (centers[i]+centers[i+1])/2)
+			}
+			centers = next
+		}
+		for i, match := range round {
+			if i < len(centers) {
+				boxes[match] = image.Pt(bracketPadding+col*bracketColWidth, centers[i]-bracketBoxRow)
+			}
+		}
+	}
+	if b.ThirdPlace != nil {
+		boxes[b.ThirdPlace] = image.Pt(bracketPadding+(len(b.Rounds)-1)*bracketColWidth,
+			height-bracketPadding-bracketBoxRow*2)
+	}
+	return
+}
+
+// RenderPNG 渲染对阵图图片，字体缺少队名的字形时返回错误
+func (b *Bracket) RenderPNG() (result []byte, err error) {
+	fnt, err := loadScoreboardFont()
+	if err != nil {
+		return
+	}
+
+	width, height, boxes := b.bracketBoxes()
+	labels := make([]string, 0, len(boxes)*2)
+	for match := range boxes {
+		labels = append(labels, match.Host.Label(), match.Guest.Label())
+	}
+	if err = checkFontGlyphs(fnt, labels...); err != nil {
+		return
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(scoreboardBg), image.Point{}, draw.Src)
+
+	for match, pt := range boxes {
+		for i, slot := range []*BracketSlot{match.Host, match.Guest} {
+			score := match.HostScore
+			if i == 1 {
+				score = match.GuestScore
+			}
+			row := image.Rect(pt.X, pt.Y+i*bracketBoxRow, pt.X+bracketBoxWidth, pt.Y+(i+1)*bracketBoxRow-2)
+			draw.Draw(img, row, image.NewUniform(scoreboardPanel), image.Point{}, draw.Src)
+
+			col := color.Color(scoreboardSilver)
+			if match.WinnerID != "" && match.WinnerID == slot.TeamID {
+				col = scoreboardGold
+			}
+			drawText(img, fnt, 18, slot.Label(), pt.X+10, row.Max.Y-8, alignLeft, col)
+			drawText(img, fnt, 18, score, row.Max.X-20, row.Max.Y-8, alignCenter, col)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	err = png.Encode(buf, img)
+	if err != nil {
+		return
+	}
+	result = buf.Bytes()
+	return
+}
+
+// RenderSVG 渲染对阵图矢量图，适合在网页中展示
+func (b *Bracket) RenderSVG() []byte {
+	width, height, boxes := b.bracketBoxes()
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="16">`,
+		width, height, width, height)
+	fmt.Fprintf(buf, `<rect width="100%%" height="100%%" fill="#8a1538"/>`)
+
+	for _, round := range append(b.Rounds, []*BracketMatch{b.ThirdPlace}) {
+		for _, match := range round {
+			pt, ok := boxes[match]
+			if !ok {
+				continue
+			}
+			for i, slot := range []*BracketSlot{match.Host, match.Guest} {
+				score := match.HostScore
+				if i == 1 {
+					score = match.GuestScore
+				}
+				fill := "#d9d9d9"
+				if match.WinnerID != "" && match.WinnerID == slot.TeamID {
+					fill = "#f2c24b"
+				}
+				y := pt.Y + i*bracketBoxRow
+				fmt.Fprintf(buf, `<rect x="%d" y="%d" width="%d" height="%d" fill="#5c0e25"/>`,
+					pt.X, y, bracketBoxWidth, bracketBoxRow-2)
+				fmt.Fprintf(buf, `<text x="%d" y="%d" fill="%s">%s</text>`,
+					pt.X+10, y+bracketBoxRow-10, fill, xmlEscape(slot.Label()))
+				fmt.Fprintf(buf, `<text x="%d" y="%d" fill="%s" text-anchor="middle">%s</text>`,
+					pt.X+bracketBoxWidth-20, y+bracketBoxRow-10, fill, xmlEscape(score))
+			}
+		}
+	}
+	buf.WriteString(`</svg>`)
+	return buf.Bytes()
+}
+
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(s)
+}
+
+// notifyBracket 淘汰赛完赛或小组赛全部结束后推送最新对阵图(文本 + 图片)
+func notifyBracket(ctx context.Context, events []*RaceEvent) (err error) {
+	if !conf.PushBracket {
+		return
+	}
+
+	var trigger *RaceEvent
+	for _, event := range events {
+		if !containsString(event.EventTypes(), EventFullTime) {
+			continue
+		}
+		if event.Match.MatchType != GroupMatchType || groupStageFinished(getLatestMatches()) {
+			trigger = event
+			break
+		}
+	}
+	if trigger == nil {
+		return
+	}
+
+	bracket := buildBracket(getLatestMatches())
+	messages := make([]interface{}, 0, 2)
+	messages = append(messages, &WeComMarkdownPush{
+		Msgtype:  "markdown",
+		Markdown: &WeComMarkdown{Content: bracket.Text()},
+	})
+	// 内置字体不含中文，只有配置了 scoreboard_font 才推送图片
+	if conf.ScoreboardFont != "" {
+		if pngByte, renderErr := bracket.RenderPNG(); renderErr != nil {
+			log.Printf("渲染对阵图失败, err[%s]", renderErr.Error())
+		} else {
+			messages = append(messages, makeImageMessage(pngByte))
+		}
+	}
+
+	err = broadcast(ctx, &Broadcast{
+		Name:     "对阵图",
+		Key:      "bracket",
+		Event:    trigger,
+		Messages: messages,
+	})
+	return
+}
+
+// groupStageFinished 小组赛是否已全部结束
+func groupStageFinished(matches []*Match) bool {
+	found := false
+	for _, match := range matches {
+		if match.MatchType != GroupMatchType {
+			continue
+		}
+		if match.Status != StatusFinished {
+			return false
+		}
+		found = true
+	}
+	return found
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestBuildBracket 小组前两名填入 1/8 决赛，胜者(含点球、从下一轮对阵推断)进入下一轮
+func TestBuildBracket(t *testing.T) {
+	names := map[string]string{"5": "英格兰", "6": "美国"}
+	race := func(id, dateTime, matchType, group, host, guest, status, hostScore, guestScore string) *Match {
+		r := testRace(id, dateTime, host, guest, status, hostScore, guestScore)
+		r.MatchType, r.GroupName = matchType, group
+		if r.HostTeamName == "" {
+			r.HostTeamName = names[host]
+		}
+		if r.GuestTeamName == "" {
+			r.GuestTeamName = names[guest]
+		}
+		match, err := newMatch(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return match
+	}
+	matches := []*Match{
+		race("1", "2022-11-21 00:00:00", GroupMatchType, "A", "1", "2", "3", "2", "0"),
+		race("2", "2022-11-21 03:00:00", GroupMatchType, "B", "5", "6", "3", "1", "0"),
+		// 1A 厄瓜多尔 vs 2B 美国，点球决出胜负
+		race("49", "2022-12-03 23:00:00", "2", "", "1", "6", "3", "1(4)", "1(3)"),
+		// 1B 英格兰 vs 2A 荷兰，数据源没有点球比分，荷兰出现在第 60 场的对阵中
+		race("52", "2022-12-05 03:00:00", "2", "", "5", "2", "3", "1", "1"),
+		race("58", "2022-12-10 03:00:00", "3", "", "0", "0", "1", "-", "-"),
+		race("60", "2022-12-11 03:00:00", "3", "", "0", "2", "1", "-", "-"),
+	}
+
+	bracket := buildBracket(matches)
+	if len(bracket.Rounds) != 4 || bracket.ThirdPlace == nil || bracket.ThirdPlace.No != 63 {
+		t.Fatalf("对阵图结构错误 %+v", bracket)
+	}
+	byNo := make(map[int]*BracketMatch)
+	for _, round := range bracket.Rounds {
+		for _, match := range round {
+			byNo[match.No] = match
+		}
+	}
+
+	m49 := byNo[49]
+	if m49.Host.TeamID != "1" || m49.Guest.TeamID != "6" || m49.WinnerID != "1" || !strings.Contains(m49.Detail, "点球") {
+		t.Errorf("第49场为 %s，胜者[%s]", m49.Text(), m49.WinnerID)
+	}
+	if m52 := byNo[52]; m52.Host.TeamID != "5" || m52.Guest.TeamID != "2" || m52.WinnerID != "2" {
+		t.Errorf("第52场为 %s，胜者[%s]", m52.Text(), m52.WinnerID)
+	}
+	if m58 := byNo[58]; m58.Host.TeamID != "1" || m58.Guest.Label() != "第50场胜者" {
+		t.Errorf("第58场为 %s", m58.Text())
+	}
+	if m60 := byNo[60]; m60.Host.Label() != "第51场胜者" || m60.Guest.TeamID != "2" {
+		t.Errorf("第60场为 %s", m60.Text())
+	}
+	if host := bracket.ThirdPlace.Host.Label(); host != "第61场负者" {
+		t.Errorf("季军赛主队为 %s", host)
+	}
+	// 没有比赛数据的小组不填入种子，显示来源
+	if slot := byNo[50].Host; slot.TeamID != "" || slot.Label() != "C组第1" {
+		t.Errorf("第50场主队为 %s", slot.Label())
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Broadcast 发往各渠道的一组消息，如积分榜、出线形势、对阵图、赛前提醒、日报
+type Broadcast struct {
+	// Name 推送名称，用于日志与错误信息，如 "A组积分榜"
+	Name string
+	// Key 免打扰积压时 Key 相同的只保留最新一组，如 "standings-A"，为空时不去重
+	Key string
+	// Expire 积压的过期时间，过期后不再补发(如开赛后的赛前提醒)，零值为不过期
+	Expire time.Time
+	// Event 按渠道的过滤条件筛选，为 nil 时发往全部企业微信渠道
+	Event *RaceEvent
+	// Messages 各渠道相同的消息
+	Messages []interface{}
+	// MakeMessages 按渠道(时区)生成消息，不为 nil 时代替 Messages
+	MakeMessages func(channel *ChannelConfig) []interface{}
+	// Payload webhook 渠道收到的消息，为 nil 时只发往企业微信渠道
+	Payload *EventPayload
+}
+
+// broadcast 推送到匹配的渠道，免打扰中的企业微信渠道先积压，结束后由 flushQuietQueues 补发
+func broadcast(ctx context.Context, b *Broadcast) (err error) {
+	for _, channel := range conf.getChannels() {
+		if b.Event != nil && !channel.matchFilter(b.Event) {
+			continue
+		}
+		if sendErr := b.sendTo(ctx, channel); sendErr != nil {
+			err = sendErr
+		}
+	}
+	return
+}
+
+// sendTo 推送到单个渠道，企业微信渠道免打扰中时积压，webhook 渠道不参与免打扰
+func (b *Broadcast) sendTo(ctx context.Context, channel *ChannelConfig) (err error) {
+	messages := b.Messages
+	if b.MakeMessages != nil {
+		messages = b.MakeMessages(channel)
+	}
+	if !channel.isWeCom() {
+		messages = nil
+		if b.Payload != nil {
+			messages = []interface{}{b.Payload}
+		}
+	}
+	if len(messages) == 0 {
+		return
+	}
+	msgs := make([]json.RawMessage, 0, len(messages))
+	for _, msg := range messages {
+		msgByte, _ := json.Marshal(msg)
+		msgs = append(msgs, msgByte)
+	}
+
+	if channel.isWeCom() && channel.inQuietHours(clock.Now()) {
+		enqueueQuietMessage(channel, &QuietMessage{Name: b.Name, Key: b.Key, Expire: b.Expire, Msgs: msgs})
+		log.Printf("免打扰中，积压%s[%s]", b.Name, channel.Name)
+		return
+	}
+
+	err = postMessages(ctx, channel, b.Name, msgs)
+	if err == nil {
+		log.Printf("推送%s[%s]", b.Name, channel.Name)
+	}
+	return
+}
+
+// postMessages 依次推送一组消息，失败的已写入死信文件，返回最近一次错误
+func postMessages(ctx context.Context, channel *ChannelConfig, name string, msgs []json.RawMessage) (err error) {
+	for _, msg := range msgs {
+		if postErr := postWithRetry(ctx, channel.Webhook, msg); postErr != nil {
+			err = fmt.Errorf("%s推送失败，已写入死信文件[%s]: [%s]%s",
+				name, DeadLetterFile, channel.Name, postErr.Error())
+		}
+	}
+	return
+}
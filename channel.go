@@ -0,0 +1,58 @@
+package main
+
+// matchFilter 判断事件是否需要推送到该渠道
+func (c *ChannelConfig) matchFilter(event *RaceEvent) bool {
+	f := c.Filter
+	if f == nil {
+		return true
+	}
+	return f.matchRace(event.Match) && f.matchEvent(event)
+}
+
+func (f *ChannelFilter) matchRace(match *Match) bool {
+	if len(f.Groups) == 0 && len(f.Stages) == 0 && len(f.Teams) == 0 {
+		return true
+	}
+
+	if match.Group != "" && containsString(f.Groups, match.Group) {
+		return true
+	}
+	for _, stage := range f.Stages {
+		if matchStage(stage, match) {
+			return true
+		}
+	}
+	return containsString(f.Teams, match.Host.ID) || containsString(f.Teams, match.Guest.ID)
+}
+
+func (f *ChannelFilter) matchEvent(event *RaceEvent) bool {
+	if len(f.Events) == 0 {
+		return true
+	}
+	for _, eventType := range event.EventTypes() {
+		if containsString(f.Events, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchStage stage 可以是 match_type、match_type_name 或 group/knockout
+func matchStage(stage string, match *Match) bool {
+	switch stage {
+	case "group":
+		return match.MatchType == GroupMatchType
+	case "knockout":
+		return match.MatchType != "" && match.MatchType != GroupMatchType
+	}
+	return stage == match.MatchType || stage == match.MatchTypeName
+}
+
+func containsString(list []string, target string) bool {
+	for _, item := range list {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
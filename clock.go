@@ -0,0 +1,71 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock 时间来源，所有与当前时间相关的判断都通过 clock 获取，replay 与测试中替换为 FakeClock
+type Clock interface {
+	Now() time.Time
+}
+
+// clock 当前使用的时钟，replay 与测试通过 setClock 替换，替换与读取可能在不同协程中
+var clock = &switchClock{source: realClock{}}
+
+// switchClock 可在运行中替换时间来源的时钟
+type switchClock struct {
+	lock   sync.RWMutex
+	source Clock
+}
+
+func (c *switchClock) Now() time.Time {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.source.Now()
+}
+
+// setClock 替换时间来源，返回原来的时钟
+func setClock(source Clock) (prev Clock) {
+	clock.lock.Lock()
+	defer clock.lock.Unlock()
+	prev = clock.source
+	clock.source = source
+	return
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// FakeClock 手动拨动的时钟
+type FakeClock struct {
+	lock sync.Mutex
+	now  time.Time
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+// Set 拨到指定时间
+func (c *FakeClock) Set(now time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = now
+}
+
+// Advance 向前拨动 d
+func (c *FakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// usage 子命令说明
+const usage = `用法: fifa-update [--dry-run] [--dry-run-out 文件] [--live] [子命令] [参数]
+选项可以放在子命令前后任意位置，多余的参数会报错
+  run                    常驻运行(默认)
+  once                   拉取、比较、推送一次后退出，适合 cron 调用
+  replay <dir>           按录制时间顺序把快照目录中的数据源响应重放一遍，默认 dry-run，--live 时真正推送
+  test-notify <channel>  向指定渠道推送一张示例卡片
+  validate               检查配置与推送模板
+  replay-dead-letter     重放死信文件`
+
+// runCommand 执行子命令
+func runCommand(ctx context.Context, name string, args []string) (err error) {
+	// 子命令需要的参数个数，多余的参数(如放错位置的选项)直接报错，避免被忽略
+	argCount, ok := map[string]int{
+		"run": 0, "once": 0, "replay": 1, "test-notify": 1, "validate": 0, "replay-dead-letter": 0,
+	}[name]
+	if !ok {
+		err = fmt.Errorf("未知的子命令[%s]\n%s", name, usage)
+		return
+	}
+	if len(args) != argCount {
+		err = fmt.Errorf("子命令[%s]需要 %d 个参数，实际为 %q\n%s", name, argCount, args, usage)
+		return
+	}
+
+	switch name {
+	case "run":
+		if err = setup(); err != nil {
+			return
+		}
+		err = runDaemon(ctx)
+	case "once":
+		if err = setup(); err != nil {
+			return
+		}
+		err = runOnce(ctx)
+	case "replay":
+		// 重放的是历史数据，误推到生产群的代价很高，默认只输出推送内容
+		if !*replayLive {
+			*dryRun = true
+		}
+		if err = setup(); err != nil {
+			return
+		}
+		err = replaySnapshots(ctx, args[0])
+	case "test-notify":
+		if err = setup(); err != nil {
+			return
+		}
+		err = testNotify(ctx, args[0])
+	case "validate":
+		err = validate()
+	case "replay-dead-letter":
+		// 与其他推送子命令一样先读取配置，推送路径依赖 conf
+		if err = setup(); err != nil {
+			return
+		}
+		err = replayDeadLetter(ctx)
+	}
+	return
+}
+
+// setup 读取配置与球迷登记
+func setup() (err error) {
+	conf, err = loadConfig(ConfigFile)
+	if err != nil {
+		err = fmt.Errorf("读取配置失败: %s", err.Error())
+		return
+	}
+	if err = initFans(); err != nil {
+		err = fmt.Errorf("读取球迷登记失败: %s", err.Error())
+		return
+	}
+	// 字体不可用时比分图会被静默跳过，启动时醒目提示
+	if conf.ScoreboardImage {
+		if fontErr := checkScoreboardFont(); fontErr != nil {
+			log.Printf("!!! 警告: 已开启 scoreboard_image，但 scoreboard_font[%s]无法渲染中文，不会推送比分图, err[%s]",
+				conf.ScoreboardFont, fontErr.Error())
+		}
+	}
+	return
+}
+
+// runOnce 单次拉取、比较、推送，比赛状态与免打扰积压保存在 StateFile 中供下次运行
+// 赛前提醒、日报依赖常驻进程的内存状态，只在 run 中进行
+func runOnce(ctx context.Context) (err error) {
+	work, cancel := newWorkContext(ctx)
+	defer cancel()
+
+	if err = loadLocalState(); err != nil {
+		return
+	}
+	flushQuietQueues(work)
+	refreshData(work, true)
+	err = saveLocalState()
+	return
+}
+
+// testNotify 向指定渠道推送示例卡片，用于检查 webhook 与模板
+func testNotify(ctx context.Context, name string) (err error) {
+	var channel *ChannelConfig
+	for _, c := range conf.getChannels() {
+		if c.Name == name {
+			channel = c
+		}
+	}
+	if channel == nil {
+		err = fmt.Errorf("渠道[%s]不存在", name)
+		return
+	}
+
+	var msg interface{} = makePush(sampleMatch(), channel.location())
+	if !channel.isWeCom() {
+		msg = makeEventPayload(&RaceEvent{Match: sampleMatch()})
+	}
+	msgByte, _ := json.Marshal(msg)
+	if err = httpPostJson(ctx, channel.Webhook, msgByte); err != nil {
+		return
+	}
+	log.Printf("示例卡片已推送到[%s]", channel.Name)
+	return
+}
+
+// validate 检查配置文件与各推送模板能否正常生成
+func validate() (err error) {
+	if err = setup(); err != nil {
+		return
+	}
+
+	names := make(map[string]bool)
+	for _, channel := range conf.Channels {
+		if channel.Name == "" || names[channel.Name] {
+			return fmt.Errorf("渠道名[%s]为空或重复", channel.Name)
+		}
+		names[channel.Name] = true
+		if channel.Webhook == "" {
+			return fmt.Errorf("渠道[%s]的 webhook 为空", channel.Name)
+		}
+		for _, quiet := range channel.QuietHours {
+			if _, err = parseClock(quiet.Start); err != nil {
+				return fmt.Errorf("渠道[%s]的免打扰时间[%s]无效", channel.Name, quiet.Start)
+			}
+			if _, err = parseClock(quiet.End); err != nil {
+				return fmt.Errorf("渠道[%s]的免打扰时间[%s]无效", channel.Name, quiet.End)
+			}
+		}
+		if channel.Filter != nil {
+			for _, event := range channel.Filter.Events {
+				if !containsString([]string{EventKickoff, EventGoal, EventFullTime, EventUpdate, EventReminder}, event) {
+					return fmt.Errorf("渠道[%s]的事件类型[%s]无效", channel.Name, event)
+				}
+			}
+		}
+	}
+	if conf.DigestTime != "" {
+		if _, err = parseClock(conf.DigestTime); err != nil {
+			return fmt.Errorf("digest_time[%s]无效", conf.DigestTime)
+		}
+	}
+
+	sample := sampleMatch()
+	for _, channel := range conf.getChannels() {
+		loc := channel.location()
+		if _, err = json.Marshal(makePush(sample, loc)); err != nil {
+			return fmt.Errorf("赛况卡片模板无效: %s", err.Error())
+		}
+		if len(splitMarkdown(makeDigest([]*Match{sample}, sample.Kickoff, loc), WeComMarkdownLimit)) == 0 {
+			return errors.New("日报模板无效")
+		}
+	}
+	if conf.ScoreboardImage {
+		if err = checkScoreboardFont(); err != nil {
+			return fmt.Errorf("scoreboard_font[%s]无效: %s", conf.ScoreboardFont, err.Error())
+		}
+	}
+	if _, err = json.Marshal(makeEventPayload(&RaceEvent{Match: sample})); err != nil {
+		return fmt.Errorf("webhook 事件模板无效: %s", err.Error())
+	}
+
+	log.Printf("配置有效，共 %d 个推送渠道。", len(conf.getChannels()))
+	return
+}
+
+// sampleMatch sampleRace 解析后的比赛，开赛时间跟随 source_timezone，需在 setup 之后调用
+func sampleMatch() *Match {
+	match, _ := newMatch(sampleRace)
+	return match
+}
+
+// sampleRace 示例比赛，用于 test-notify 与 validate
+var sampleRace = &FifaScheduleList{
+	TeamID:           "1",
+	Date:             "2022-11-21",
+	DateTime:         "2022-11-21 00:00:00",
+	HostTeamID:       "3",
+	GuestTeamID:      "1",
+	HostTeamName:     "卡塔尔",
+	GuestTeamName:    "厄瓜多尔",
+	HostTeamScore:    "0",
+	GuestTeamScore:   "2",
+	MatchStatus:      "3",
+	MatchDes:         "完赛",
+	MatchType:        "1",
+	MatchTypeName:    "小组赛",
+	MatchTypeDes:     "第1轮",
+	GroupName:        "A",
+	HostTeamLogoURL:  "https://juhe.oss-cn-hangzhou.aliyuncs.com/api_image/616/worldcup2022/A3.png",
+	GuestTeamLogoURL: "https://juhe.oss-cn-hangzhou.aliyuncs.com/api_image/616/worldcup2022/A1.png",
+}
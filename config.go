@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"time"
+	_ "time/tzdata" // 容器中可能没有时区数据库
+)
+
+// ConfigFile 可选的配置文件，不存在时全部使用默认配置
+const ConfigFile = "config.json"
+
+// 渠道类型
+const (
+	ChannelWeCom   = "wecom"
+	ChannelWebhook = "webhook"
+)
+
+// conf 当前生效的配置
+var conf = defaultConfig()
+
+type Config struct {
+	// SourceTimezone 数据源中比赛时间所在的时区，timeMap 也按该时区匹配
+	SourceTimezone string `json:"source_timezone"`
+	// HttpAddr 内置 http 服务监听地址，如 ":8080"，为空时不启动
+	HttpAddr string `json:"http_addr"`
+	// Fans 球迷登记，对应球队进球或丢球时在推送中 @ 他们
+	Fans []*FanConfig `json:"fans"`
+	// ReminderMinutes 赛前提醒的提前分钟数，如 [60, 10]，为空时不提醒
+	ReminderMinutes []int `json:"reminder_minutes"`
+	// DigestTime 每日推送日报的时间，格式 "HH:MM"，为空时不推送
+	DigestTime string `json:"digest_time"`
+	// PushStandings 小组赛完赛后是否推送该组积分榜
+	PushStandings bool `json:"push_standings"`
+	// PushLiveStandings 小组赛最后一轮进球改变出线形势时是否推送实时积分榜
+	PushLiveStandings bool `json:"push_live_standings"`
+	// ScenarioMinutes 小组最后一轮开赛前多少分钟推送出线形势，为 0 时不推送
+	ScenarioMinutes int `json:"scenario_minutes"`
+	// PushBracket 淘汰赛完赛(及小组赛全部结束)后是否推送对阵图
+	PushBracket bool `json:"push_bracket"`
+	// AdminToken 管理接口的 Bearer token，为空时不开放管理接口
+	AdminToken string `json:"admin_token"`
+	// SnapshotDir 保存每次数据源响应的目录，供 replay 子命令重放，为空时不保存
+	SnapshotDir string `json:"snapshot_dir"`
+	// ScoreboardImage 推送赛况卡片后，是否再推送一张比分图片
+	ScoreboardImage bool `json:"scoreboard_image"`
+	// ScoreboardFont 比分图使用的字体文件(ttf/otf)，为空时使用内置的 Go 字体。
+	// 内置字体不包含中文字形，显示中文队名需要指定一个中文字体，如 NotoSansCJK，否则不推送比分图
+	ScoreboardFont string `json:"scoreboard_font"`
+	// Channels 推送目的地，为空时只推送到 RobotApi
+	Channels []*ChannelConfig `json:"channels"`
+}
+
+// ChannelConfig 推送目的地，Name 需唯一
+type ChannelConfig struct {
+	Name string `json:"name"`
+	// Type 渠道类型: "wecom"(默认，企业微信机器人) 或 "webhook"(POST EventPayload json)
+	Type    string         `json:"type"`
+	Webhook string         `json:"webhook"`
+	Filter  *ChannelFilter `json:"filter"`
+	// QuietHours 免打扰时段，期间的变更在结束后汇总成一条推送
+	QuietHours []*QuietHours `json:"quiet_hours"`
+	// Timezone 展示开赛时间、判断免打扰与日报时间使用的时区，为空时同 SourceTimezone
+	Timezone string `json:"timezone"`
+
+	loc *time.Location
+}
+
+// ChannelFilter 推送过滤条件
+// Groups、Stages、Teams 之间是"或"的关系，命中任意一个即推送，都为空时不限比赛；
+// Events 为空时不限事件类型
+type ChannelFilter struct {
+	// Groups 小组名，如 "B"
+	Groups []string `json:"groups"`
+	// Stages 比赛阶段，可填 match_type("1"~"6")、match_type_name("半决赛")、
+	// 或 "group"(小组赛)、"knockout"(淘汰赛)
+	Stages []string `json:"stages"`
+	// Teams 球队 id，主客队任一命中即可
+	Teams []string `json:"teams"`
+	// Events 事件类型，见 EventKickoff 等，赛前提醒为 EventReminder
+	Events []string `json:"events"`
+}
+
+type FanConfig struct {
+	TeamID  string   `json:"team_id"`
+	UserIDs []string `json:"user_ids"`
+	Mobiles []string `json:"mobiles"`
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		SourceTimezone: "Asia/Shanghai",
+		Fans:           make([]*FanConfig, 0),
+		Channels:       make([]*ChannelConfig, 0),
+	}
+}
+
+// channelByURL 按推送地址查找渠道，找不到时返回 nil
+func (c *Config) channelByURL(url string) *ChannelConfig {
+	for _, channel := range c.getChannels() {
+		if channel.Webhook == url {
+			return channel
+		}
+	}
+	return nil
+}
+
+// getChannels 获取推送目的地，没有配置时使用 RobotApi
+func (c *Config) getChannels() []*ChannelConfig {
+	if len(c.Channels) > 0 {
+		return c.Channels
+	}
+	return []*ChannelConfig{{
+		Name:    "default",
+		Webhook: RobotApi,
+	}}
+}
+
+// loadConfig 读取配置文件，文件不存在时保持默认配置
+func loadConfig(path string) (result *Config, err error) {
+	result = defaultConfig()
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		log.Printf("未找到配置文件[%s]，使用默认配置。", path)
+		data, err = []byte("{}"), nil
+	}
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(data, result)
+	if err != nil {
+		return
+	}
+
+	err = result.loadTimezones()
+	return
+}
+
+// loadTimezones 解析数据源与各渠道的时区
+func (c *Config) loadTimezones() (err error) {
+	if c.SourceTimezone != "" {
+		loc, locErr := time.LoadLocation(c.SourceTimezone)
+		if locErr != nil {
+			err = fmt.Errorf("source_timezone[%s]无效: %s", c.SourceTimezone, locErr.Error())
+			return
+		}
+		TournamentLocation = loc
+	}
+
+	for _, channel := range c.Channels {
+		if !channel.isWeCom() && channel.Type != ChannelWebhook {
+			err = fmt.Errorf("渠道[%s]的 type[%s]无效", channel.Name, channel.Type)
+			return
+		}
+		if channel.Timezone == "" {
+			continue
+		}
+		channel.loc, err = time.LoadLocation(channel.Timezone)
+		if err != nil {
+			err = fmt.Errorf("渠道[%s]的 timezone[%s]无效: %s", channel.Name, channel.Timezone, err.Error())
+			return
+		}
+	}
+	return
+}
+
+// getWeComChannels 企业微信渠道，提醒、积分榜、日报等只推送到这些渠道
+func (c *Config) getWeComChannels() []*ChannelConfig {
+	result := make([]*ChannelConfig, 0)
+	for _, channel := range c.getChannels() {
+		if channel.isWeCom() {
+			result = append(result, channel)
+		}
+	}
+	return result
+}
+
+func (c *ChannelConfig) isWeCom() bool {
+	return c.Type == "" || c.Type == ChannelWeCom
+}
+
+// location 渠道的展示时区
+func (c *ChannelConfig) location() *time.Location {
+	if c.loc != nil {
+		return c.loc
+	}
+	return TournamentLocation
+}
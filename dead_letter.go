@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+// DeadLetterFile 重试后仍推送失败的消息会追加到该文件，每行一条 json
+const DeadLetterFile = "dead_letter.jsonl"
+
+var deadLetterLock sync.Mutex
+
+type DeadLetter struct {
+	Time string          `json:"time"`
+	URL  string          `json:"url"`
+	Msg  json.RawMessage `json:"msg"`
+	Err  string          `json:"err"`
+}
+
+// saveDeadLetter 追加一条死信
+func saveDeadLetter(url string, msg []byte, sendErr error) (err error) {
+	deadLetterLock.Lock()
+	defer deadLetterLock.Unlock()
+
+	letter := &DeadLetter{
+		Time: clock.Now().Format(DateTimBarFormat),
+		URL:  url,
+		Msg:  msg,
+		Err:  sendErr.Error(),
+	}
+	line, err := json.Marshal(letter)
+	if err != nil {
+		return
+	}
+
+	f, err := os.OpenFile(DeadLetterFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return
+}
+
+// loadDeadLetters 读取全部死信
+func loadDeadLetters() (result []*DeadLetter, err error) {
+	result = make([]*DeadLetter, 0)
+	f, err := os.Open(DeadLetterFile)
+	if os.IsNotExist(err) {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		letter := &DeadLetter{}
+		if jsonErr := json.Unmarshal([]byte(line), letter); jsonErr != nil {
+			log.Printf("跳过无法解析的死信, err[%s], line[%s]", jsonErr.Error(), line)
+			continue
+		}
+		result = append(result, letter)
+	}
+	err = scanner.Err()
+	return
+}
+
+// replayDeadLetter 重新推送死信，仍失败的保留在文件中
+func replayDeadLetter(ctx context.Context) (err error) {
+	deadLetterLock.Lock()
+	defer deadLetterLock.Unlock()
+
+	letters, err := loadDeadLetters()
+	if err != nil {
+		return
+	}
+	if len(letters) == 0 {
+		log.Printf("没有需要重放的死信。")
+		return
+	}
+
+	remain := make([]byte, 0)
+	for _, letter := range letters {
+		sendErr := postWithBackoff(ctx, letter.URL, letter.Msg)
+		if sendErr == nil {
+			log.Printf("死信重放成功：[%s]%s", letter.Time, string(letter.Msg))
+			continue
+		}
+
+		letter.Err = sendErr.Error()
+		line, _ := json.Marshal(letter)
+		remain = append(remain, line...)
+		remain = append(remain, '\n')
+	}
+
+	// dry-run 时消息并没有真正送达，保留死信文件
+	if *dryRun {
+		return
+	}
+	err = ioutil.WriteFile(DeadLetterFile, remain, 0644)
+	if err != nil {
+		return
+	}
+
+	if len(remain) > 0 {
+		err = fmt.Errorf("部分死信重放失败，已保留在[%s]", DeadLetterFile)
+	}
+	return
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WeComMarkdownLimit 企业微信 markdown 消息内容上限为 4096 字节
+const WeComMarkdownLimit = 4096
+
+var digestLock sync.Mutex
+
+// checkDigest 按各渠道时区到达 conf.DigestTime 后推送一次日报，免打扰中的渠道积压到免打扰结束，
+// webhook 渠道收到 PayloadDigest 类型的消息
+func checkDigest(ctx context.Context) {
+	var err error
+	defer func() {
+		if err != nil {
+			log.Printf("err[%s]", err.Error())
+			reportErr(ctx, getErrStr(err))
+		}
+	}()
+
+	if conf.DigestTime == "" {
+		return
+	}
+	digestMinute, err := parseClock(conf.DigestTime)
+	if err != nil {
+		return
+	}
+	data := getLatestMatches()
+	if len(data) == 0 {
+		return
+	}
+
+	digestLock.Lock()
+	defer digestLock.Unlock()
+
+	for _, channel := range conf.getChannels() {
+		loc := channel.location()
+		now := clock.Now().In(loc)
+		today := now.Format("2006-01-02")
+		if now.Hour()*60+now.Minute() < digestMinute || state.digestSent[channel.Name] == today {
+			continue
+		}
+		startTime := state.digestStartTime.In(loc)
+		if startTime.Format("2006-01-02") == today && startTime.Hour()*60+startTime.Minute() > digestMinute {
+			continue
+		}
+		state.digestSent[channel.Name] = today
+
+		lines := makeDigest(data, now, loc)
+		messages := make([]interface{}, 0)
+		for _, content := range splitMarkdown(lines, WeComMarkdownLimit) {
+			messages = append(messages, &WeComMarkdownPush{
+				Msgtype:  "markdown",
+				Markdown: &WeComMarkdown{Content: content},
+			})
+		}
+		digest := &Broadcast{
+			Name:     today + "日报",
+			Key:      "digest",
+			Messages: messages,
+			Payload:  &EventPayload{Type: PayloadDigest, Time: clock.Now(), Content: strings.Join(lines, "\n")},
+		}
+		if pushErr := digest.sendTo(ctx, channel); pushErr != nil {
+			err = pushErr
+		}
+	}
+}
+
+// makeDigest 日报的 markdown 行: 上一个比赛日的赛果、小组积分榜、今日赛程。
+// 比赛日按 Match.Date(数据源的 schedule_date)划分，不按 loc 重新分组，标题日期与开赛时间按 loc 展示
+func makeDigest(matches []*Match, now time.Time, loc *time.Location) (result []string) {
+	result = []string{fmt.Sprintf("**📰 世界杯日报 %s**", now.In(loc).Format("2006-01-02"))}
+
+	today := now.In(TournamentLocation).Format("2006-01-02")
+	yesterday := ""
+	dayMap := make(map[string][]*Match)
+	for _, match := range matches {
+		dayMap[match.Date] = append(dayMap[match.Date], match)
+		if match.Date < today && match.Date > yesterday {
+			yesterday = match.Date
+		}
+	}
+
+	if yesterday != "" {
+		result = append(result, "", fmt.Sprintf("**%s 赛果**", yesterday))
+		for _, match := range dayMap[yesterday] {
+			hostScore, guestScore := match.scoreText()
+			result = append(result, fmt.Sprintf("> %s %s : %s %s <font color=\"comment\">【%s】%s</font>",
+				match.Host.Name, hostScore, guestScore, match.Guest.Name, match.StatusDesc, match.Score.Detail()))
+		}
+	}
+
+	standings := computeGroupStandings(matches)
+	for _, group := range sortedGroupNames(standings) {
+		rows := standings[group]
+		if !groupStarted(rows) {
+			continue
+		}
+		result = append(result, "", fmt.Sprintf("**%s组积分榜**", group))
+		result = append(result, formatStandings(rows)...)
+	}
+
+	result = append(result, "", "**今日赛程**")
+	if len(dayMap[today]) == 0 {
+		result = append(result, "> 今日无比赛")
+		return
+	}
+	for _, match := range dayMap[today] {
+		result = append(result, fmt.Sprintf("> %s %s vs %s <font color=\"comment\">%s</font>",
+			match.Kickoff.In(loc).Format("15:04"), match.Host.Name, match.Guest.Name, match.stageText()))
+	}
+	return
+}
+
+// splitMarkdown 按行拆分，保证每段不超过 limit 字节
+func splitMarkdown(lines []string, limit int) (result []string) {
+	current := ""
+	for _, line := range lines {
+		if current != "" && len(current)+1+len(line) > limit {
+			result = append(result, current)
+			current = ""
+		}
+		if current == "" {
+			current = line
+		} else {
+			current += "\n" + line
+		}
+	}
+	if strings.TrimSpace(current) != "" {
+		result = append(result, current)
+	}
+	return
+}
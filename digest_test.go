@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMakeDigestScheduleDate 上一个比赛日按数据源的 ScheduleDate 划分，不按渠道时区重新分组
+func TestMakeDigestScheduleDate(t *testing.T) {
+	previous := testRace("1", "2022-11-20 23:00:00", "3", "1", "3", "0", "2")
+	midnight := testRace("2", "2022-11-21 00:00:00", "4", "2", "3", "0", "2")
+	evening := testRace("3", "2022-11-21 21:00:00", "3", "4", "1", "-", "-")
+	input, _ := toMatches(groupByDate([]*FifaScheduleList{previous, midnight, evening}))
+
+	// 布宜诺斯艾利斯 11-21 09:00 即数据源时区 11-21 20:00，零点的比赛在当地是 11-20
+	loc, err := time.LoadLocation("America/Argentina/Buenos_Aires")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2022, 11, 21, 9, 0, 0, 0, loc)
+	content := strings.Join(makeDigest(input, now, loc), "\n")
+
+	results := content[strings.Index(content, "赛果"):strings.Index(content, "积分榜")]
+	if !strings.Contains(results, "卡塔尔 0 : 2 厄瓜多尔") || strings.Contains(results, "塞内加尔") {
+		t.Fatalf("赛果应只包含 11-20 的比赛:\n%s", results)
+	}
+	fixtures := content[strings.Index(content, "今日赛程"):]
+	if !strings.Contains(fixtures, "塞内加尔 vs 荷兰") || !strings.Contains(fixtures, "卡塔尔 vs 塞内加尔") {
+		t.Fatalf("今日赛程应包含 11-21 的两场比赛:\n%s", fixtures)
+	}
+	if !strings.Contains(content, "日报 2022-11-21") {
+		t.Fatalf("标题日期应按渠道时区:\n%s", content)
+	}
+}
+
+// TestDigestWebhook 日报同时发往企业微信和 webhook 渠道
+func TestDigestWebhook(t *testing.T) {
+	h := newHarness(t)
+	conf.DigestTime = "09:00"
+	conf.Channels = []*ChannelConfig{
+		{Name: "default", Webhook: RobotApi},
+		{Name: "hook", Type: ChannelWebhook, Webhook: h.wecom.URL + "/hook"},
+	}
+	h.juhe.script(juheOK(testRace("1", "2022-11-21 00:00:00", "3", "1", "3", "0", "2")))
+
+	h.tick("2022-11-21 07:45:00")
+	h.expect()
+
+	h.tick("2022-11-21 09:00:00")
+	messages := h.expect("/robot:markdown", "/hook:")
+	if messages[1].Type != PayloadDigest || !strings.Contains(messages[1].Content, "今日赛程") {
+		t.Fatalf("webhook 收到 type[%s] content[%s]，期望日报", messages[1].Type, messages[1].Content)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"sync"
+)
+
+var (
+	// dryRun 只输出推送内容，不真正推送
+	dryRun = flag.Bool("dry-run", false, "只输出推送内容，不真正推送")
+	// dryRunOut dry-run 输出文件，为空时输出到标准输出
+	dryRunOut = flag.String("dry-run-out", "", "dry-run 输出文件(追加写入)，默认标准输出")
+	// replayLive replay 子命令默认按 dry-run 执行，指定后才真正推送
+	replayLive = flag.Bool("live", false, "replay 时真正推送，默认只输出推送内容")
+
+	dryRunLock sync.Mutex
+)
+
+// DryRunRecord dry-run 输出的一行
+type DryRunRecord struct {
+	Time    string          `json:"time"`
+	Channel string          `json:"channel"`
+	URL     string          `json:"url"`
+	Msg     json.RawMessage `json:"msg"`
+}
+
+// writeDryRun 把本应推送的消息按 jsonl 输出
+func writeDryRun(url string, msg []byte) (err error) {
+	record := &DryRunRecord{
+		Time:    clock.Now().Format(DateTimBarFormat),
+		Channel: channelNameByURL(url),
+		URL:     url,
+		Msg:     msg,
+	}
+	if !json.Valid(msg) {
+		record.Msg, _ = json.Marshal(string(msg))
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+
+	dryRunLock.Lock()
+	defer dryRunLock.Unlock()
+
+	var out io.Writer = os.Stdout
+	if *dryRunOut != "" {
+		f, openErr := os.OpenFile(*dryRunOut, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if openErr != nil {
+			return openErr
+		}
+		defer f.Close()
+		out = f
+	}
+	_, err = out.Write(append(line, '\n'))
+	return
+}
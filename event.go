@@ -0,0 +1,97 @@
+package main
+
+// 事件类型，一次变更可能同时属于多个类型(如终场前最后一刻的进球)
+const (
+	EventKickoff  = "kickoff"  // 开赛
+	EventGoal     = "goal"     // 进球
+	EventFullTime = "fulltime" // 完赛
+	EventUpdate   = "update"   // 其他变更，如比分修正
+	EventReminder = "reminder" // 赛前提醒
+)
+
+// RaceEvent 一次检测到的比赛变更
+type RaceEvent struct {
+	Match *Match
+	// Prev 变更前的本地记录，本地没有记录时为 nil
+	Prev *RaceState
+	// ReminderMinutes 赛前提醒距开赛的分钟数，普通变更为 0
+	ReminderMinutes int
+}
+
+// RaceState 本地记录的比赛状态与比分，原样保存数据源的字段，未开赛时比分为 "-"
+type RaceState struct {
+	Status     string
+	HostScore  string
+	GuestScore string
+}
+
+// prevStatus 变更前的比赛状态，本地没有记录时为 StatusUnknown
+func (e *RaceEvent) prevStatus() MatchStatus {
+	if e.Prev == nil {
+		return StatusUnknown
+	}
+	status, _ := parseMatchStatus(e.Prev.Status)
+	return status
+}
+
+// prevScore 变更前的比分，本地没有记录或未开赛时视为 0:0
+func (e *RaceEvent) prevScore() (host, guest int) {
+	if e.Prev == nil {
+		return
+	}
+	host, _, _, _ = parseScoreField(e.Prev.HostScore)
+	guest, _, _, _ = parseScoreField(e.Prev.GuestScore)
+	// 未开赛的比分 "-" 解析为 -1
+	if host < 0 {
+		host = 0
+	}
+	if guest < 0 {
+		guest = 0
+	}
+	return
+}
+
+// GoalTeamIDs 本次变更中进球的球队和丢球的球队
+func (e *RaceEvent) GoalTeamIDs() (scored, conceded []string) {
+	if e.Match.Score == nil {
+		return
+	}
+	prevHost, prevGuest := e.prevScore()
+	host, guest, match := e.Match.Score.Host, e.Match.Score.Guest, e.Match
+
+	if host > prevHost {
+		scored = append(scored, match.Host.ID)
+		conceded = append(conceded, match.Guest.ID)
+	}
+	if guest > prevGuest {
+		scored = append(scored, match.Guest.ID)
+		conceded = append(conceded, match.Host.ID)
+	}
+	return
+}
+
+// EventTypes 本次变更所属的事件类型
+func (e *RaceEvent) EventTypes() (result []string) {
+	if e.ReminderMinutes > 0 {
+		result = append(result, EventReminder)
+		return
+	}
+
+	if e.Match.Status != e.prevStatus() {
+		switch e.Match.Status {
+		case StatusLive:
+			result = append(result, EventKickoff)
+		case StatusFinished:
+			result = append(result, EventFullTime)
+		}
+	}
+
+	if scored, _ := e.GoalTeamIDs(); len(scored) > 0 {
+		result = append(result, EventGoal)
+	}
+
+	if len(result) == 0 {
+		result = append(result, EventUpdate)
+	}
+	return
+}
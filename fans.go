@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// FansFile 通过 http 接口登记的球迷会保存在这里，重启后仍然有效。
+// 配置文件中的球迷不写入该文件，只能通过修改配置文件删除
+const FansFile = "fans.json"
+
+var fansLock sync.RWMutex
+
+// initFans 读取 FansFile，与配置文件中的球迷合并
+func initFans() (err error) {
+	fansLock.Lock()
+	defer fansLock.Unlock()
+
+	state.apiFansMap = make(map[string]*FanConfig)
+	defer rebuildFans()
+
+	data, err := ioutil.ReadFile(FansFile)
+	if os.IsNotExist(err) {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	saved := make([]*FanConfig, 0)
+	err = json.Unmarshal(data, &saved)
+	if err != nil {
+		return
+	}
+	for _, fan := range saved {
+		mergeFan(state.apiFansMap, fan)
+	}
+	return
+}
+
+// rebuildFans 重新合并配置文件与 http 接口登记的球迷，调用方需持有 fansLock
+func rebuildFans() {
+	state.fansMap = make(map[string]*FanConfig)
+	for _, fan := range conf.Fans {
+		mergeFan(state.fansMap, fan)
+	}
+	for _, fan := range state.apiFansMap {
+		mergeFan(state.fansMap, fan)
+	}
+}
+
+// mergeFan 把 fan 合并到 target，调用方需持有 fansLock
+func mergeFan(target map[string]*FanConfig, fan *FanConfig) {
+	if fan == nil || fan.TeamID == "" {
+		return
+	}
+	exist, ok := target[fan.TeamID]
+	if !ok {
+		exist = &FanConfig{TeamID: fan.TeamID}
+		target[fan.TeamID] = exist
+	}
+	exist.UserIDs = appendUnique(exist.UserIDs, fan.UserIDs...)
+	exist.Mobiles = appendUnique(exist.Mobiles, fan.Mobiles...)
+}
+
+// removeFan 从 target 中删除 fan，调用方需持有 fansLock
+func removeFan(target map[string]*FanConfig, fan *FanConfig) {
+	exist, ok := target[fan.TeamID]
+	if !ok {
+		return
+	}
+	exist.UserIDs = removeItems(exist.UserIDs, fan.UserIDs...)
+	exist.Mobiles = removeItems(exist.Mobiles, fan.Mobiles...)
+	if len(exist.UserIDs) == 0 && len(exist.Mobiles) == 0 {
+		delete(target, fan.TeamID)
+	}
+}
+
+// saveFans 保存 http 接口登记的球迷，调用方需持有 fansLock，dry-run 时不保存
+func saveFans() (err error) {
+	if *dryRun {
+		return
+	}
+	list := make([]*FanConfig, 0, len(state.apiFansMap))
+	for _, fan := range state.apiFansMap {
+		list = append(list, fan)
+	}
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return
+	}
+	err = ioutil.WriteFile(FansFile, data, 0644)
+	return
+}
+
+// getFanMentions 获取若干球队的球迷，用于 @ 提醒
+func getFanMentions(teamIDs ...string) (userIDs, mobiles []string) {
+	fansLock.RLock()
+	defer fansLock.RUnlock()
+
+	for _, teamID := range teamIDs {
+		fan, ok := state.fansMap[teamID]
+		if !ok {
+			continue
+		}
+		userIDs = appendUnique(userIDs, fan.UserIDs...)
+		mobiles = appendUnique(mobiles, fan.Mobiles...)
+	}
+	return
+}
+
+// makeGoalMention 进球时 @ 进球方与丢球方的球迷，没有相关球迷时返回 nil
+func makeGoalMention(event *RaceEvent) (result *WeComTextPush) {
+	scored, conceded := event.GoalTeamIDs()
+	if len(scored) == 0 {
+		return
+	}
+
+	userIDs, mobiles := getFanMentions(append(scored, conceded...)...)
+	if len(userIDs) == 0 && len(mobiles) == 0 {
+		return
+	}
+
+	match := event.Match
+	scorer := make([]string, 0, len(scored))
+	for _, teamID := range scored {
+		if teamID == match.Host.ID {
+			scorer = append(scorer, match.Host.Name)
+		} else {
+			scorer = append(scorer, match.Guest.Name)
+		}
+	}
+	hostScore, guestScore := match.scoreText()
+
+	result = &WeComTextPush{
+		Msgtype: "text",
+		Text: &WeComText{
+			Content: fmt.Sprintf("⚽ %s 进球！\n%s %s : %s %s",
+				strings.Join(scorer, "、"),
+				match.Host.Name, hostScore, guestScore, match.Guest.Name),
+			MentionedList:       userIDs,
+			MentionedMobileList: mobiles,
+		},
+	}
+	return
+}
+
+// handleFans 球迷登记接口
+// GET 查询全部登记；POST 登记；DELETE 取消登记，POST/DELETE 需要 admin token，body 为 FanConfig。
+// DELETE 只能取消通过接口登记的球迷，配置文件中的球迷仍然保留
+func handleFans(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeFans(w)
+	case http.MethodPost, http.MethodDelete:
+		withAdminAuth(handleFansUpdate)(w, r)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handleFansUpdate POST/DELETE /api/fans 登记、取消登记
+func handleFansUpdate(w http.ResponseWriter, r *http.Request) {
+	fan := &FanConfig{}
+	err := json.NewDecoder(r.Body).Decode(fan)
+	if err == nil && fan.TeamID == "" {
+		err = errors.New("team_id 不能为空")
+	}
+	if err != nil {
+		writeJsonErr(w, http.StatusBadRequest, err)
+		return
+	}
+
+	fansLock.Lock()
+	if r.Method == http.MethodPost {
+		mergeFan(state.apiFansMap, fan)
+	} else {
+		removeFan(state.apiFansMap, fan)
+	}
+	rebuildFans()
+	err = saveFans()
+	fansLock.Unlock()
+	if err != nil {
+		log.Printf("保存球迷登记失败, err[%s]", err.Error())
+		writeJsonErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeFans(w)
+}
+
+// writeFans 返回全部登记的球迷
+func writeFans(w http.ResponseWriter) {
+	fansLock.RLock()
+	list := make([]*FanConfig, 0, len(state.fansMap))
+	for _, fan := range state.fansMap {
+		list = append(list, fan)
+	}
+	fansLock.RUnlock()
+	writeJson(w, list)
+}
+
+func appendUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		if item == "" {
+			continue
+		}
+		found := false
+		for _, exist := range list {
+			if exist == item {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func removeItems(list []string, items ...string) []string {
+	result := make([]string, 0, len(list))
+	for _, exist := range list {
+		keep := true
+		for _, item := range items {
+			if exist == item {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			result = append(result, exist)
+		}
+	}
+	return result
+}
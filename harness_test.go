@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeJuhe 模拟聚合数据赛程接口，每次请求依次返回预设的响应，用完后重复最后一个
+type fakeJuhe struct {
+	*httptest.Server
+
+	lock      sync.Mutex
+	responses []string
+	requests  int
+}
+
+func newFakeJuhe() *fakeJuhe {
+	f := &fakeJuhe{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+		body := `{"reason":"暂无数据","result":null,"error_code":10001}`
+		if len(f.responses) > 0 {
+			index := f.requests
+			if index >= len(f.responses) {
+				index = len(f.responses) - 1
+			}
+			body = f.responses[index]
+		}
+		f.requests++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	return f
+}
+
+// script 设置后续请求依次返回的响应
+func (f *fakeJuhe) script(responses ...string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.responses = append(f.responses, responses...)
+}
+
+func (f *fakeJuhe) requestCount() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.requests
+}
+
+// juheOK 成功的赛程响应，同数据源一样按比赛日期分组
+func juheOK(races ...*FifaScheduleList) string {
+	data, _ := json.Marshal(&Fifa{
+		Reason: "查询成功",
+		Result: &FifaResult{Data: groupByDate(races)},
+	})
+	return string(data)
+}
+
+// groupByDate 按比赛日期分组成 FifaData，日期按出现顺序排列
+func groupByDate(races []*FifaScheduleList) (result []*FifaData) {
+	days := make(map[string]*FifaData)
+	for _, race := range races {
+		day := days[race.Date]
+		if day == nil {
+			day = &FifaData{ScheduleDate: race.Date}
+			days[race.Date] = day
+			result = append(result, day)
+		}
+		day.ScheduleList = append(day.ScheduleList, race)
+	}
+	return
+}
+
+// juheErr 接口报错的响应
+func juheErr(code int, reason string) string {
+	return fmt.Sprintf(`{"reason":%q,"result":null,"error_code":%d}`, reason, code)
+}
+
+// sentMessage 模拟机器人收到的一条消息
+type sentMessage struct {
+	Path string
+	// Errcode 模拟机器人对这条消息返回的 errcode
+	Errcode int
+
+	Msgtype      string         `json:"msgtype"`
+	TemplateCard *TemplateCard  `json:"template_card"`
+	Text         *WeComText     `json:"text"`
+	Markdown     *WeComMarkdown `json:"markdown"`
+	Image        *WeComImage    `json:"image"`
+
+	// Type、Events、PrevHostScore、Content webhook 渠道收到的 EventPayload
+	Type          string   `json:"type"`
+	Events        []string `json:"events"`
+	PrevHostScore *int     `json:"prev_host_score"`
+	Content       string   `json:"content"`
+}
+
+// fakeWeCom 模拟企业微信机器人，记录收到的消息，可按路径预设返回的 errcode
+type fakeWeCom struct {
+	*httptest.Server
+
+	lock     sync.Mutex
+	messages []*sentMessage
+	// map[path] = 后续请求依次返回的 errcode
+	errcodes map[string][]int
+}
+
+func newFakeWeCom() *fakeWeCom {
+	f := &fakeWeCom{errcodes: make(map[string][]int)}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		msg := &sentMessage{}
+		if err := json.Unmarshal(body, msg); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		msg.Path = r.URL.Path
+
+		f.lock.Lock()
+		if codes := f.errcodes[msg.Path]; len(codes) > 0 {
+			msg.Errcode, f.errcodes[msg.Path] = codes[0], codes[1:]
+		}
+		f.messages = append(f.messages, msg)
+		f.lock.Unlock()
+
+		_ = json.NewEncoder(w).Encode(&WeComResp{Errcode: msg.Errcode, Errmsg: "ok"})
+	}))
+	return f
+}
+
+// failNext 让 path 后续的请求依次返回 errcodes
+func (f *fakeWeCom) failNext(path string, errcodes ...int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.errcodes[path] = append(f.errcodes[path], errcodes...)
+}
+
+// take 取出并清空目前收到的消息
+func (f *fakeWeCom) take() []*sentMessage {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	result := f.messages
+	f.messages = nil
+	return result
+}
+
+// harness 把数据源、机器人替换为模拟服务，用模拟时钟驱动完整的定时流程
+type harness struct {
+	t     *testing.T
+	juhe  *fakeJuhe
+	wecom *fakeWeCom
+	clock *FakeClock
+}
+
+func newHarness(t *testing.T) *harness {
+	h := &harness{
+		t:     t,
+		juhe:  newFakeJuhe(),
+		wecom: newFakeWeCom(),
+		clock: NewFakeClock(time.Now()),
+	}
+
+	// 死信、球迷登记、国旗缓存等文件写到临时目录
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	oldFifaApi, oldRobotApi, oldErrReportApi := FifaApi, RobotApi, ErrReportApi
+	FifaApi = h.juhe.URL + "/schedule"
+	RobotApi = h.wecom.URL + "/robot"
+	ErrReportApi = h.wecom.URL + "/err"
+
+	conf = defaultConfig()
+	useFreshState(t)
+	markFetchSuccess(h.clock.Now())
+	setClock(h.clock)
+
+	t.Cleanup(func() {
+		setClock(realClock{})
+		FifaApi, RobotApi, ErrReportApi = oldFifaApi, oldRobotApi, oldErrReportApi
+		conf = defaultConfig()
+		h.juhe.Close()
+		h.wecom.Close()
+		_ = os.Chdir(wd)
+	})
+	return h
+}
+
+// useFreshState 测试期间使用全新的运行状态，结束后恢复原来的状态
+func useFreshState(t *testing.T) {
+	prev := state
+	state = newRunState()
+	t.Cleanup(func() {
+		state = prev
+	})
+}
+
+// tick 把时钟拨到 at(数据源时区，格式同 DateTimBarFormat)，执行一次定时检查
+func (h *harness) tick(at string) {
+	h.t.Helper()
+	now, err := time.ParseInLocation(DateTimBarFormat, at, TournamentLocation)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	h.clock.Set(now)
+	runTick(context.Background(), false)
+}
+
+// expect 检查本次 tick 收到的消息类型，格式为 "path:msgtype"，返回收到的消息
+func (h *harness) expect(want ...string) []*sentMessage {
+	h.t.Helper()
+	messages := h.wecom.take()
+	got := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		got = append(got, msg.Path+":"+msg.Msgtype)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		h.t.Fatalf("收到的消息为 %v，期望 %v", got, want)
+	}
+	return messages
+}
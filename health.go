@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ReadyFetchMaxAge 比赛进行期间，距最近一次成功拉取超过该时长视为卡住(检查时间内每 15 分钟拉取一次)
+const ReadyFetchMaxAge = 30 * time.Minute
+
+// MatchWindow 开赛后多久内视为比赛进行中，覆盖加时与点球
+const MatchWindow = 3 * time.Hour
+
+// ErrReportWindow 错误通知推送失败后多久内视为不就绪，之后不再影响 /readyz
+const ErrReportWindow = 30 * time.Minute
+
+var healthLock sync.Mutex
+
+// markFetchSuccess 记录一次成功的数据拉取，启动时也以启动时间调用一次
+func markFetchSuccess(t time.Time) {
+	healthLock.Lock()
+	defer healthLock.Unlock()
+	state.freshSince = t
+}
+
+// markErrReport 记录错误通知的推送结果
+func markErrReport(t time.Time, err error) {
+	healthLock.Lock()
+	defer healthLock.Unlock()
+	state.errReportErr, state.errReportTime = err, t
+}
+
+// inMatchWindow 当前是否有比赛正在进行(状态为进行中，或开赛后 MatchWindow 内仍未完赛)
+func inMatchWindow(matches []*Match, now time.Time) bool {
+	for _, match := range matches {
+		if match.Status == StatusLive {
+			return true
+		}
+		if match.Status == StatusFinished {
+			continue
+		}
+		if !now.Before(match.Kickoff) && now.Before(match.Kickoff.Add(MatchWindow)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadySlotGrace 检查时间到达后多久仍未成功拉取视为错过(定时器每分钟检查一次)
+const ReadySlotGrace = 5 * time.Minute
+
+// lastScheduledTime 不晚于 now 的最近一个 timeMap 检查时间
+func lastScheduledTime(now time.Time) (result time.Time, ok bool) {
+	now = now.In(TournamentLocation)
+	for _, t := range timeMap {
+		clockTime, err := time.Parse(time.Kitchen, t)
+		if err != nil {
+			continue
+		}
+		// 今天的检查时间还没到时取昨天的
+		for _, day := range []time.Time{now, now.AddDate(0, 0, -1)} {
+			slot := time.Date(day.Year(), day.Month(), day.Day(), clockTime.Hour(), clockTime.Minute(), 0, 0, TournamentLocation)
+			if slot.After(now) {
+				continue
+			}
+			if !ok || slot.After(result) {
+				result, ok = slot, true
+			}
+			break
+		}
+	}
+	return
+}
+
+// checkReady 检查是否可以正常工作，返回不就绪的原因。
+// 比赛进行中、距最近一次成功拉取超过 ReadyFetchMaxAge、且期间有 timeMap 中的检查时间时判定为卡住；
+// 检查时间之外不拉取是正常的，单次拉取失败也会在下一次检查时间重试，都不影响就绪
+func checkReady(now time.Time, matches []*Match) (err error) {
+	healthLock.Lock()
+	fetchTime, reportErr, reportTime := state.freshSince, state.errReportErr, state.errReportTime
+	healthLock.Unlock()
+
+	if reportErr != nil && now.Sub(reportTime) < ErrReportWindow {
+		err = fmt.Errorf("错误通知推送失败: %s", reportErr.Error())
+		return
+	}
+
+	age := now.Sub(fetchTime)
+	if age <= ReadyFetchMaxAge || !inMatchWindow(matches, now) {
+		return
+	}
+	// 最近的检查时间早于上次成功拉取，说明比赛不在 timeMap 内，本来就不会拉取
+	slot, ok := lastScheduledTime(now)
+	if !ok || !slot.After(fetchTime) || now.Sub(slot) < ReadySlotGrace {
+		return
+	}
+	err = fmt.Errorf("比赛进行中，但已 %s 未成功拉取数据，错过了检查时间 %s", age.Truncate(time.Second),
+		slot.In(TournamentLocation).Format(DateTimBarFormat))
+	return
+}
+
+// handleHealthz GET /healthz 进程存活
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJson(w, map[string]string{"status": "ok"})
+}
+
+// handleReadyz GET /readyz 比赛期间数据拉取卡住或错误通知推送失败时返回 503
+func handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if err := checkReady(clock.Now(), getLatestMatches()); err != nil {
+		writeJsonErr(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	writeJson(w, map[string]string{"status": "ok"})
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tournamentTime 解析数据源时区的时间，格式同 DateTimBarFormat
+func tournamentTime(t *testing.T, value string) time.Time {
+	t.Helper()
+	result, err := time.ParseInLocation(DateTimBarFormat, value, TournamentLocation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestCheckReady(t *testing.T) {
+	cases := []struct {
+		name       string
+		fetch      string
+		now        string
+		race       *FifaScheduleList
+		reportErr  error
+		reportTime string
+		wantErr    bool
+	}{
+		{name: "刚拉取过", fetch: "2022-11-21 00:00:00", now: "2022-11-21 00:10:00",
+			race: testRace("1", "2022-11-21 00:00:00", "3", "1", "2", "0", "0")},
+		{name: "没有比赛时长时间未拉取", fetch: "2022-11-21 08:00:00", now: "2022-11-21 22:00:00",
+			race: testRace("1", "2022-11-22 00:00:00", "3", "1", "1", "-", "-")},
+		{name: "比赛中错过检查时间", fetch: "2022-11-21 00:00:00", now: "2022-11-21 01:10:00",
+			race: testRace("1", "2022-11-21 00:00:00", "3", "1", "2", "0", "0"), wantErr: true},
+		{name: "开赛后状态未更新也算比赛中", fetch: "2022-11-21 00:00:00", now: "2022-11-21 01:10:00",
+			race: testRace("1", "2022-11-21 00:00:00", "3", "1", "1", "-", "-"), wantErr: true},
+		{name: "检查时间刚到还在宽限内", fetch: "2022-11-21 00:00:00", now: "2022-11-21 00:48:00",
+			race: testRace("1", "2022-11-21 00:00:00", "3", "1", "2", "0", "0")},
+		{name: "比赛不在 timeMap 内", fetch: "2022-11-21 07:45:00", now: "2022-11-21 19:00:00",
+			race: testRace("1", "2022-11-21 18:00:00", "3", "1", "2", "0", "0")},
+		{name: "已完赛", fetch: "2022-11-21 00:00:00", now: "2022-11-21 02:10:00",
+			race: testRace("1", "2022-11-21 00:00:00", "3", "1", "3", "0", "2")},
+		{name: "错误通知刚推送失败", fetch: "2022-11-21 08:00:00", now: "2022-11-21 10:00:00",
+			race:      testRace("1", "2022-11-22 00:00:00", "3", "1", "1", "-", "-"),
+			reportErr: errors.New("timeout"), reportTime: "2022-11-21 09:50:00", wantErr: true},
+		{name: "错误通知推送失败已过期", fetch: "2022-11-21 08:00:00", now: "2022-11-21 10:00:00",
+			race:      testRace("1", "2022-11-22 00:00:00", "3", "1", "1", "-", "-"),
+			reportErr: errors.New("timeout"), reportTime: "2022-11-21 09:00:00"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			useFreshState(t)
+			markFetchSuccess(tournamentTime(t, c.fetch))
+			reportTime := time.Time{}
+			if c.reportTime != "" {
+				reportTime = tournamentTime(t, c.reportTime)
+			}
+			markErrReport(reportTime, c.reportErr)
+
+			match, err := newMatch(c.race)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = checkReady(tournamentTime(t, c.now), []*Match{match})
+			if (err != nil) != c.wantErr {
+				t.Fatalf("checkReady = %v, wantErr %v", err, c.wantErr)
+			}
+		})
+	}
+}
+
+// TestHandleReadyz 比赛中错过检查时间返回 503，恢复拉取后返回 200，/healthz 始终返回 200
+func TestHandleReadyz(t *testing.T) {
+	useFreshState(t)
+	setClock(NewFakeClock(tournamentTime(t, "2022-11-21 01:10:00")))
+	defer setClock(realClock{})
+
+	match, err := newMatch(testRace("1", "2022-11-21 00:00:00", "3", "1", "2", "0", "0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setLatestMatches([]*Match{match})
+
+	serve := func(handler http.HandlerFunc, target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+		return recorder
+	}
+
+	markFetchSuccess(tournamentTime(t, "2022-11-21 00:00:00"))
+	if resp := serve(handleReadyz, "/readyz"); resp.Code != http.StatusServiceUnavailable {
+		t.Errorf("拉取卡住时 /readyz 返回 %d", resp.Code)
+	}
+	if resp := serve(handleHealthz, "/healthz"); resp.Code != http.StatusOK {
+		t.Errorf("/healthz 返回 %d", resp.Code)
+	}
+
+	markFetchSuccess(clock.Now())
+	resp := serve(handleReadyz, "/readyz")
+	if resp.Code != http.StatusOK || !strings.Contains(resp.Body.String(), `"ok"`) {
+		t.Errorf("恢复后 /readyz 返回 %d: %s", resp.Code, resp.Body.String())
+	}
+}
@@ -2,27 +2,36 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 )
 
 const DateTimBarFormat = "2006-01-02 15:04:05"
 
-// FifaApi 数据源 api 的地址
-const FifaApi = "http://apis.juhe.cn/fapigw/worldcup2022/schedule?type=&key=xxxxxxxxx"
+// 以下地址为变量，测试中替换为本地的模拟服务
+var (
+	// FifaApi 数据源 api 的地址
+	FifaApi = "http://apis.juhe.cn/fapigw/worldcup2022/schedule?type=&key=xxxxxxxxx"
 
-// RobotApi 推送赛况通知的企业微信机器人 Api
-const RobotApi = "https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=xxxxxxxxx"
+	// RobotApi 推送赛况通知的企业微信机器人 Api
+	RobotApi = "https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=xxxxxxxxx"
 
-// ErrReportApi 推送错误通知的企业微信机器人 Api(可以和上面的一致)
-const ErrReportApi = "https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=xxxxxxxxx"
+	// ErrReportApi 推送错误通知的企业微信机器人 Api(可以和上面的一致)
+	ErrReportApi = "https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=xxxxxxxxx"
+)
 
 var timeMap = []string{ // 免费接口调用次数要少于 50 次, 首次启动程序需要浪费一次调用初始化数据
 	/*
@@ -63,24 +72,88 @@ var timeMap = []string{ // 免费接口调用次数要少于 50 次, 首次启
 	"7:00AM", "7:15AM", "7:30AM", "7:45AM",
 }
 
-// map[teamID] = [matchStatus-HostReamScore-GuestTeamScore]
-var localMap map[string]string
+// refreshLock 定时刷新与管理接口可能同时读写 localMap
+var refreshLock sync.Mutex
 
 func main() {
-	refreshData()
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// flag 遇到第一个非选项参数就停止解析，逐个取出子命令与参数后继续解析其后的选项，
+	// 这样 once --dry-run、replay snapshots/ --dry-run 中的选项也会生效
+	name, args, rest := "run", make([]string, 0), flag.Args()
+	for len(rest) > 0 {
+		args = append(args, rest[0])
+		if err := flag.CommandLine.Parse(rest[1:]); err != nil {
+			os.Exit(2)
+		}
+		rest = flag.Args()
+	}
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+
+	// 收到 SIGINT/SIGTERM 后 ctx 取消，进行中的任务在 ShutdownTimeout 内继续完成
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := runCommand(ctx, name, args); err != nil {
+		log.Fatalf("%s 失败, err[%s]", name, err.Error())
+	}
+}
+
+// runDaemon 常驻运行，每分钟检查一次，ctx 取消后做完当前检查、推送积压消息、保存状态后退出
+func runDaemon(ctx context.Context) (err error) {
+	work, cancel := newWorkContext(ctx)
+	defer cancel()
+	workCtx = work
+	markFetchSuccess(clock.Now())
+	state.digestStartTime = clock.Now()
+
+	if err = loadLocalState(); err != nil {
+		return
+	}
+	server := startHttpServer()
 
+	// 启动时总是拉取一次，即便已从 state.json 恢复了比分，latestMatches 也只在内存中，
+	// 不在检查时间内重启时摘要、提醒、查询接口都依赖这次拉取
+	refreshData(work, true)
+
+	done := make(chan struct{})
 	GoWithRecovery(func() {
-		ticker := time.Tick(time.Second * time.Duration(60))
+		defer close(done)
+		ticker := time.NewTicker(time.Second * time.Duration(60))
+		defer ticker.Stop()
 		for {
 			select {
-			case <-ticker:
-				refreshData()
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				runTick(work, false)
 			}
 		}
 	})
 
-	select {}
+	<-done
+	log.Printf("准备退出，等待进行中的任务完成。")
+	shutdownHttpServer(server)
+	flushQuietQueues(work)
+	if err = saveLocalState(); err != nil {
+		return
+	}
+	log.Printf("退出完成。")
+	return
+}
 
+// runTick 一次定时检查，force 时忽略检查时间立即拉取
+func runTick(ctx context.Context, force bool) {
+	flushQuietQueues(ctx)
+	refreshData(ctx, force)
+	checkReminders(ctx)
+	checkDigest(ctx)
+	checkScenarios(ctx)
 }
 
 func checkIsTime() bool {
@@ -89,7 +162,8 @@ func checkIsTime() bool {
 		return true
 	}
 
-	now := time.Now().Format(time.Kitchen)
+	// timeMap 按数据源时区配置
+	now := clock.Now().In(TournamentLocation).Format(time.Kitchen)
 	for _, t := range timeMap {
 		if t == now {
 			log.Printf("\n===\n命中检查时间[%s]", t)
@@ -102,93 +176,131 @@ func checkIsTime() bool {
 	return false
 }
 
-func refreshData() {
+// refreshData 拉取数据并推送变更，force 时忽略检查时间
+func refreshData(ctx context.Context, force bool) {
+	refreshLock.Lock()
+	defer refreshLock.Unlock()
+
 	var err error
 	defer func() {
 		if err != nil {
 			log.Printf("err[%s]", err.Error())
-			httpPostJson(ErrReportApi, getErrStr(err))
+			reportErr(ctx, getErrStr(err))
 		}
 	}()
 
-	if !checkIsTime() {
+	if !force && !checkIsTime() {
 		return
 	}
 
-	fifa, err := grabFifa()
+	fifa, err := grabFifa(ctx)
 	if err != nil {
 		return
 	}
+	err = processData(ctx, fifa)
+}
+
+// processData 比较新数据并推送变更，调用方需持有 refreshLock。
+// 数据源的原始数据在这里校验并转换成 Match，之后只使用 Match
+func processData(ctx context.Context, fifa []*FifaData) (err error) {
+	matches, malformed := toMatches(fifa)
+	setLatestMatches(matches)
+	reportMalformed(ctx, malformed)
 
-	needPush, diffData, err := diffLocal(fifa)
+	needPush, diffData, err := diffLocal(matches)
 	if err != nil {
 		return
 	}
+	diffsTotal.Add(float64(len(diffData)))
 
 	if !needPush {
 		log.Println("无需推送，跳过。")
 		return
 	}
 
-	err = notifyWeCom(diffData)
-	if err != nil {
-		return
-	}
+	publishEvents(diffData)
 
+	// localMap 已更新，某个渠道推送失败也要继续其余推送，否则积分榜等不会再有机会发出
+	errMsgs := make([]string, 0)
+	for _, notify := range []func(context.Context, []*RaceEvent) error{
+		notifyWeCom, notifyStandings, notifyLiveStandings, notifyBracket,
+	} {
+		if notifyErr := notify(ctx, diffData); notifyErr != nil {
+			errMsgs = append(errMsgs, notifyErr.Error())
+		}
+	}
+	if len(errMsgs) > 0 {
+		err = errors.New(strings.Join(errMsgs, "\n"))
+	}
 	return
 }
 
 // grabFifa 拉数据
-func grabFifa() (result []*FifaData, err error) {
+func grabFifa(ctx context.Context) (result []*FifaData, err error) {
 	result = make([]*FifaData, 0)
-	getData, err := httpGetJson(FifaApi)
+	getData, err := httpGetJson(ctx, FifaApi)
 	if err != nil {
+		recordFetch(FetchHttpError)
 		return
 	}
 	// demo test struct
 	//get := `{"reason":"查询成功","result":{"data":[{"schedule_date":"2022-11-21","schedule_date_format":"11月21日","schedule_week":"周一","schedule_current":"0","schedule_list":[{"team_id":"1","date":"2022-11-21","date_time":"2022-11-21 00:00:00","host_team_id":"3","guest_team_id":"1","host_team_name":"卡塔尔","guest_team_name":"厄瓜多尔","host_team_score":"0","guest_team_score":"2","match_status":"3","match_des":"完赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第1轮","group_name":"A","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/A3.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/A1.png"},{"team_id":"2","date":"2022-11-21","date_time":"2022-11-21 21:00:00","host_team_id":"5","guest_team_id":"6","host_team_name":"英格兰","guest_team_name":"伊朗","host_team_score":"6","guest_team_score":"2","match_status":"3","match_des":"完赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第1轮","group_name":"B","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/B5.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/B6.png"}]},{"schedule_date":"2022-11-22","schedule_date_format":"11月22日","schedule_week":"周二","schedule_current":"0","schedule_list":[{"team_id":"3","date":"2022-11-22","date_time":"2022-11-22 00:00:00","host_team_id":"4","guest_team_id":"2","host_team_name":"塞内加尔","guest_team_name":"荷兰","host_team_score":"0","guest_team_score":"2","match_status":"3","match_des":"完赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第1轮","group_name":"A","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/A4.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/A2.png"},{"team_id":"4","date":"2022-11-22","date_time":"2022-11-22 03:00:00","host_team_id":"7","guest_team_id":"8","host_team_name":"美国","guest_team_name":"威尔士","host_team_score":"1","guest_team_score":"1","match_status":"3","match_des":"完赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第1轮","group_name":"B","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/B7.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/B8.png"},{"team_id":"5","date":"2022-11-22","date_time":"2022-11-22 18:00:00","host_team_id":"9","guest_team_id":"12","host_team_name":"阿根廷","guest_team_name":"沙特阿拉伯","host_team_score":"1","guest_team_score":"2","match_status":"3","match_des":"完赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第1轮","group_name":"C","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/C9.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/C12.png"},{"team_id":"6","date":"2022-11-22","date_time":"2022-11-22 21:00:00","host_team_id":"14","guest_team_id":"16","host_team_name":"丹麦","guest_team_name":"突尼斯","host_team_score":"0","guest_team_score":"0","match_status":"3","match_des":"完赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第1轮","group_name":"D","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/D14.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/D16.png"}]},{"schedule_date":"2022-11-23","schedule_date_format":"11月23日","schedule_week":"周三","schedule_current":"0","schedule_list":[{"team_id":"7","date":"2022-11-23","date_time":"2022-11-23 00:00:00","host_team_id":"10","guest_team_id":"11","host_team_name":"墨西哥","guest_team_name":"波兰","host_team_score":"0","guest_team_score":"0","match_status":"3","match_des":"完赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第1轮","group_name":"C","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/C10.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/C11.png"},{"team_id":"8","date":"2022-11-23","date_time":"2022-11-23 03:00:00","host_team_id":"15","guest_team_id":"13","host_team_name":"法国","guest_team_name":"澳大利亚","host_team_score":"4","guest_team_score":"1","match_status":"3","match_des":"完赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第1轮","group_name":"D","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/D15.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/D13.png"},{"team_id":"9","date":"2022-11-23","date_time":"2022-11-23 18:00:00","host_team_id":"24","guest_team_id":"23","host_team_name":"摩洛哥","guest_team_name":"克罗地亚","host_team_score":"0","guest_team_score":"0","match_status":"3","match_des":"完赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第1轮","group_name":"F","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/F24.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/F23.png"},{"team_id":"10","date":"2022-11-23","date_time":"2022-11-23 21:00:00","host_team_id":"18","guest_team_id":"19","host_team_name":"德国","guest_team_name":"日本","host_team_score":"1","guest_team_score":"2","match_status":"3","match_des":"完赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第1轮","group_name":"E","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/E18.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/E19.png"}]},{"schedule_date":"2022-11-24","schedule_date_format":"11月24日","schedule_week":"周四","schedule_current":"1","schedule_list":[{"team_id":"11","date":"2022-11-24","date_time":"2022-11-24 00:00:00","host_team_id":"20","guest_team_id":"17","host_team_name":"西班牙","guest_team_name":"哥斯达黎加","host_team_score":"7","guest_team_score":"0","match_status":"3","match_des":"完赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第1轮","group_name":"E","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/E20.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/E17.png"},{"team_id":"12","date":"2022-11-24","date_time":"2022-11-24 03:00:00","host_team_id":"21","guest_team_id":"22","host_team_name":"比利时","guest_team_name":"加拿大","host_team_score":"1","guest_team_score":"0","match_status":"3","match_des":"完赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第1轮","group_name":"F","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/F21.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/F22.png"},{"team_id":"13","date":"2022-11-24","date_time":"2022-11-24 18:00:00","host_team_id":"28","guest_team_id":"26","host_team_name":"瑞士","guest_team_name":"喀麦隆","host_team_score":"1","guest_team_score":"0","match_status":"2","match_des":"进行中","match_type":"1","match_type_name":"小组赛","match_type_des":"第1轮","group_name":"G","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/G28.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/G26.png"},{"team_id":"14","date":"2022-11-24","date_time":"2022-11-24 21:00:00","host_team_id":"32","guest_team_id":"31","host_team_name":"乌拉圭","guest_team_name":"韩国","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第1轮","group_name":"H","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/H32.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/H31.png"}]},{"schedule_date":"2022-11-25","schedule_date_format":"11月25日","schedule_week":"周五","schedule_current":"0","schedule_list":[{"team_id":"15","date":"2022-11-25","date_time":"2022-11-25 00:00:00","host_team_id":"30","guest_team_id":"29","host_team_name":"葡萄牙","guest_team_name":"加纳","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第1轮","group_name":"H","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/H30.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/H29.png"},{"team_id":"16","date":"2022-11-25","date_time":"2022-11-25 03:00:00","host_team_id":"25","guest_team_id":"27","host_team_name":"巴西","guest_team_name":"塞尔维亚","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第1轮","group_name":"G","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/G25.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/G27.png"},{"team_id":"17","date":"2022-11-25","date_time":"2022-11-25 18:00:00","host_team_id":"8","guest_team_id":"6","host_team_name":"威尔士","guest_team_name":"伊朗","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第2轮","group_name":"B","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/B8.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/B6.png"},{"team_id":"18","date":"2022-11-25","date_time":"2022-11-25 21:00:00","host_team_id":"3","guest_team_id":"4","host_team_name":"卡塔尔","guest_team_name":"塞内加尔","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第2轮","group_name":"A","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/A3.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/A4.png"}]},{"schedule_date":"2022-11-26","schedule_date_format":"11月26日","schedule_week":"周六","schedule_current":"0","schedule_list":[{"team_id":"19","date":"2022-11-26","date_time":"2022-11-26 00:00:00","host_team_id":"2","guest_team_id":"1","host_team_name":"荷兰","guest_team_name":"厄瓜多尔","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第2轮","group_name":"A","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/A2.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/A1.png"},{"team_id":"20","date":"2022-11-26","date_time":"2022-11-26 03:00:00","host_team_id":"5","guest_team_id":"7","host_team_name":"英格兰","guest_team_name":"美国","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第2轮","group_name":"B","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/B5.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/B7.png"},{"team_id":"21","date":"2022-11-26","date_time":"2022-11-26 18:00:00","host_team_id":"16","guest_team_id":"13","host_team_name":"突尼斯","guest_team_name":"澳大利亚","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第2轮","group_name":"D","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/D16.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/D13.png"},{"team_id":"22","date":"2022-11-26","date_time":"2022-11-26 21:00:00","host_team_id":"11","guest_team_id":"12","host_team_name":"波兰","guest_team_name":"沙特阿拉伯","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第2轮","group_name":"C","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/C11.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/C12.png"}]},{"schedule_date":"2022-11-27","schedule_date_format":"11月27日","schedule_week":"周日","schedule_current":"0","schedule_list":[{"team_id":"23","date":"2022-11-27","date_time":"2022-11-27 00:00:00","host_team_id":"15","guest_team_id":"14","host_team_name":"法国","guest_team_name":"丹麦","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第2轮","group_name":"D","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/D15.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/D14.png"},{"team_id":"24","date":"2022-11-27","date_time":"2022-11-27 03:00:00","host_team_id":"9","guest_team_id":"10","host_team_name":"阿根廷","guest_team_name":"墨西哥","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第2轮","group_name":"C","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/C9.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/C10.png"},{"team_id":"25","date":"2022-11-27","date_time":"2022-11-27 18:00:00","host_team_id":"19","guest_team_id":"17","host_team_name":"日本","guest_team_name":"哥斯达黎加","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第2轮","group_name":"E","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/E19.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/E17.png"},{"team_id":"26","date":"2022-11-27","date_time":"2022-11-27 21:00:00","host_team_id":"21","guest_team_id":"24","host_team_name":"比利时","guest_team_name":"摩洛哥","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第2轮","group_name":"F","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/F21.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/F24.png"}]},{"schedule_date":"2022-11-28","schedule_date_format":"11月28日","schedule_week":"周一","schedule_current":"0","schedule_list":[{"team_id":"27","date":"2022-11-28","date_time":"2022-11-28 00:00:00","host_team_id":"23","guest_team_id":"22","host_team_name":"克罗地亚","guest_team_name":"加拿大","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第2轮","group_name":"F","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/F23.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/F22.png"},{"team_id":"28","date":"2022-11-28","date_time":"2022-11-28 03:00:00","host_team_id":"20","guest_team_id":"18","host_team_name":"西班牙","guest_team_name":"德国","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第2轮","group_name":"E","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/E20.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/E18.png"},{"team_id":"29","date":"2022-11-28","date_time":"2022-11-28 18:00:00","host_team_id":"26","guest_team_id":"27","host_team_name":"喀麦隆","guest_team_name":"塞尔维亚","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第2轮","group_name":"G","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/G26.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/G27.png"},{"team_id":"30","date":"2022-11-28","date_time":"2022-11-28 21:00:00","host_team_id":"31","guest_team_id":"29","host_team_name":"韩国","guest_team_name":"加纳","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第2轮","group_name":"H","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/H31.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/H29.png"}]},{"schedule_date":"2022-11-29","schedule_date_format":"11月29日","schedule_week":"周二","schedule_current":"0","schedule_list":[{"team_id":"31","date":"2022-11-29","date_time":"2022-11-29 00:00:00","host_team_id":"25","guest_team_id":"28","host_team_name":"巴西","guest_team_name":"瑞士","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第2轮","group_name":"G","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/G25.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/G28.png"},{"team_id":"32","date":"2022-11-29","date_time":"2022-11-29 03:00:00","host_team_id":"30","guest_team_id":"32","host_team_name":"葡萄牙","guest_team_name":"乌拉圭","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第2轮","group_name":"H","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/H30.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/H32.png"},{"team_id":"33","date":"2022-11-29","date_time":"2022-11-29 23:00:00","host_team_id":"2","guest_team_id":"3","host_team_name":"荷兰","guest_team_name":"卡塔尔","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第3轮","group_name":"A","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/A2.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/A3.png"},{"team_id":"34","date":"2022-11-29","date_time":"2022-11-29 23:00:00","host_team_id":"1","guest_team_id":"4","host_team_name":"厄瓜多尔","guest_team_name":"塞内加尔","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第3轮","group_name":"A","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/A1.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/A4.png"}]},{"schedule_date":"2022-11-30","schedule_date_format":"11月30日","schedule_week":"周三","schedule_current":"0","schedule_list":[{"team_id":"35","date":"2022-11-30","date_time":"2022-11-30 03:00:00","host_team_id":"8","guest_team_id":"5","host_team_name":"威尔士","guest_team_name":"英格兰","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第3轮","group_name":"B","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/B8.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/B5.png"},{"team_id":"36","date":"2022-11-30","date_time":"2022-11-30 03:00:00","host_team_id":"6","guest_team_id":"7","host_team_name":"伊朗","guest_team_name":"美国","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第3轮","group_name":"B","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/B6.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/B7.png"},{"team_id":"37","date":"2022-11-30","date_time":"2022-11-30 23:00:00","host_team_id":"16","guest_team_id":"15","host_team_name":"突尼斯","guest_team_name":"法国","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第3轮","group_name":"D","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/D16.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/D15.png"},{"team_id":"38","date":"2022-11-30","date_time":"2022-11-30 23:00:00","host_team_id":"13","guest_team_id":"14","host_team_name":"澳大利亚","guest_team_name":"丹麦","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第3轮","group_name":"D","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/D13.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/D14.png"}]},{"schedule_date":"2022-12-01","schedule_date_format":"12月01日","schedule_week":"周四","schedule_current":"0","schedule_list":[{"team_id":"39","date":"2022-12-01","date_time":"2022-12-01 03:00:00","host_team_id":"11","guest_team_id":"9","host_team_name":"波兰","guest_team_name":"阿根廷","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第3轮","group_name":"C","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/C11.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/C9.png"},{"team_id":"40","date":"2022-12-01","date_time":"2022-12-01 03:00:00","host_team_id":"12","guest_team_id":"10","host_team_name":"沙特阿拉伯","guest_team_name":"墨西哥","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第3轮","group_name":"C","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/C12.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/C10.png"},{"team_id":"41","date":"2022-12-01","date_time":"2022-12-01 23:00:00","host_team_id":"23","guest_team_id":"21","host_team_name":"克罗地亚","guest_team_name":"比利时","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第3轮","group_name":"F","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/F23.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/F21.png"},{"team_id":"42","date":"2022-12-01","date_time":"2022-12-01 23:00:00","host_team_id":"22","guest_team_id":"24","host_team_name":"加拿大","guest_team_name":"摩洛哥","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第3轮","group_name":"F","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/F22.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/F24.png"}]},{"schedule_date":"2022-12-02","schedule_date_format":"12月02日","schedule_week":"周五","schedule_current":"0","schedule_list":[{"team_id":"43","date":"2022-12-02","date_time":"2022-12-02 03:00:00","host_team_id":"19","guest_team_id":"20","host_team_name":"日本","guest_team_name":"西班牙","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第3轮","group_name":"E","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/E19.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/E20.png"},{"team_id":"44","date":"2022-12-02","date_time":"2022-12-02 03:00:00","host_team_id":"17","guest_team_id":"18","host_team_name":"哥斯达黎加","guest_team_name":"德国","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第3轮","group_name":"E","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/E17.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/E18.png"},{"team_id":"45","date":"2022-12-02","date_time":"2022-12-02 23:00:00","host_team_id":"31","guest_team_id":"30","host_team_name":"韩国","guest_team_name":"葡萄牙","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第3轮","group_name":"H","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/H31.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/H30.png"},{"team_id":"46","date":"2022-12-02","date_time":"2022-12-02 23:00:00","host_team_id":"29","guest_team_id":"32","host_team_name":"加纳","guest_team_name":"乌拉圭","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第3轮","group_name":"H","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/H29.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/H32.png"}]},{"schedule_date":"2022-12-03","schedule_date_format":"12月03日","schedule_week":"周六","schedule_current":"0","schedule_list":[{"team_id":"47","date":"2022-12-03","date_time":"2022-12-03 03:00:00","host_team_id":"27","guest_team_id":"28","host_team_name":"塞尔维亚","guest_team_name":"瑞士","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第3轮","group_name":"G","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/G27.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/G28.png"},{"team_id":"48","date":"2022-12-03","date_time":"2022-12-03 03:00:00","host_team_id":"26","guest_team_id":"25","host_team_name":"喀麦隆","guest_team_name":"巴西","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"1","match_type_name":"小组赛","match_type_des":"第3轮","group_name":"G","host_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/G26.png","guest_team_logo_url":"https:\/\/juhe.oss-cn-hangzhou.aliyuncs.com\/api_image\/616\/worldcup2022\/G25.png"},{"team_id":"49","date":"2022-12-03","date_time":"2022-12-03 23:00:00","host_team_id":"0","guest_team_id":"0","host_team_name":"A组第1","guest_team_name":"B组第2","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"2","match_type_name":"1\/8决赛","match_type_des":"","group_name":"","host_team_logo_url":null,"guest_team_logo_url":null}]},{"schedule_date":"2022-12-04","schedule_date_format":"12月04日","schedule_week":"周日","schedule_current":"0","schedule_list":[{"team_id":"50","date":"2022-12-04","date_time":"2022-12-04 03:00:00","host_team_id":"0","guest_team_id":"0","host_team_name":"C组第1","guest_team_name":"D组第2","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"2","match_type_name":"1\/8决赛","match_type_des":"","group_name":"","host_team_logo_url":null,"guest_team_logo_url":null},{"team_id":"51","date":"2022-12-04","date_time":"2022-12-04 23:00:00","host_team_id":"0","guest_team_id":"0","host_team_name":"D组第1","guest_team_name":"C组第2","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"2","match_type_name":"1\/8决赛","match_type_des":"","group_name":"","host_team_logo_url":null,"guest_team_logo_url":null}]},{"schedule_date":"2022-12-05","schedule_date_format":"12月05日","schedule_week":"周一","schedule_current":"0","schedule_list":[{"team_id":"52","date":"2022-12-05","date_time":"2022-12-05 03:00:00","host_team_id":"0","guest_team_id":"0","host_team_name":"B组第1","guest_team_name":"A组第2","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"2","match_type_name":"1\/8决赛","match_type_des":"","group_name":"","host_team_logo_url":null,"guest_team_logo_url":null},{"team_id":"53","date":"2022-12-05","date_time":"2022-12-05 23:00:00","host_team_id":"0","guest_team_id":"0","host_team_name":"E组第1","guest_team_name":"F组第2","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"2","match_type_name":"1\/8决赛","match_type_des":"","group_name":"","host_team_logo_url":null,"guest_team_logo_url":null}]},{"schedule_date":"2022-12-06","schedule_date_format":"12月06日","schedule_week":"周二","schedule_current":"0","schedule_list":[{"team_id":"54","date":"2022-12-06","date_time":"2022-12-06 03:00:00","host_team_id":"0","guest_team_id":"0","host_team_name":"G组第1","guest_team_name":"H组第2","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"2","match_type_name":"1\/8决赛","match_type_des":"","group_name":"","host_team_logo_url":null,"guest_team_logo_url":null},{"team_id":"55","date":"2022-12-06","date_time":"2022-12-06 23:00:00","host_team_id":"0","guest_team_id":"0","host_team_name":"F组第1","guest_team_name":"E组第2","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"2","match_type_name":"1\/8决赛","match_type_des":"","group_name":"","host_team_logo_url":null,"guest_team_logo_url":null}]},{"schedule_date":"2022-12-07","schedule_date_format":"12月07日","schedule_week":"周三","schedule_current":"0","schedule_list":[{"team_id":"56","date":"2022-12-07","date_time":"2022-12-07 03:00:00","host_team_id":"0","guest_team_id":"0","host_team_name":"H组第1","guest_team_name":"G组第2","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"2","match_type_name":"1\/8决赛","match_type_des":"","group_name":"","host_team_logo_url":null,"guest_team_logo_url":null}]},{"schedule_date":"2022-12-09","schedule_date_format":"12月09日","schedule_week":"周五","schedule_current":"0","schedule_list":[{"team_id":"57","date":"2022-12-09","date_time":"2022-12-09 23:00:00","host_team_id":"0","guest_team_id":"0","host_team_name":"待定","guest_team_name":"待定","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"3","match_type_name":"1\/4决赛","match_type_des":"","group_name":"","host_team_logo_url":null,"guest_team_logo_url":null}]},{"schedule_date":"2022-12-10","schedule_date_format":"12月10日","schedule_week":"周六","schedule_current":"0","schedule_list":[{"team_id":"58","date":"2022-12-10","date_time":"2022-12-10 03:00:00","host_team_id":"0","guest_team_id":"0","host_team_name":"待定","guest_team_name":"待定","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"3","match_type_name":"1\/4决赛","match_type_des":"","group_name":"","host_team_logo_url":null,"guest_team_logo_url":null},{"team_id":"59","date":"2022-12-10","date_time":"2022-12-10 23:00:00","host_team_id":"0","guest_team_id":"0","host_team_name":"待定","guest_team_name":"待定","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"3","match_type_name":"1\/4决赛","match_type_des":"","group_name":"","host_team_logo_url":null,"guest_team_logo_url":null}]},{"schedule_date":"2022-12-11","schedule_date_format":"12月11日","schedule_week":"周日","schedule_current":"0","schedule_list":[{"team_id":"60","date":"2022-12-11","date_time":"2022-12-11 03:00:00","host_team_id":"0","guest_team_id":"0","host_team_name":"待定","guest_team_name":"待定","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"3","match_type_name":"1\/4决赛","match_type_des":"","group_name":"","host_team_logo_url":null,"guest_team_logo_url":null}]},{"schedule_date":"2022-12-14","schedule_date_format":"12月14日","schedule_week":"周三","schedule_current":"0","schedule_list":[{"team_id":"61","date":"2022-12-14","date_time":"2022-12-14 03:00:00","host_team_id":"0","guest_team_id":"0","host_team_name":"待定","guest_team_name":"待定","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"4","match_type_name":"半决赛","match_type_des":"","group_name":"","host_team_logo_url":null,"guest_team_logo_url":null}]},{"schedule_date":"2022-12-15","schedule_date_format":"12月15日","schedule_week":"周四","schedule_current":"0","schedule_list":[{"team_id":"62","date":"2022-12-15","date_time":"2022-12-15 03:00:00","host_team_id":"0","guest_team_id":"0","host_team_name":"待定","guest_team_name":"待定","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"4","match_type_name":"半决赛","match_type_des":"","group_name":"","host_team_logo_url":null,"guest_team_logo_url":null}]},{"schedule_date":"2022-12-17","schedule_date_format":"12月17日","schedule_week":"周六","schedule_current":"0","schedule_list":[{"team_id":"63","date":"2022-12-17","date_time":"2022-12-17 23:00:00","host_team_id":"0","guest_team_id":"0","host_team_name":"待定","guest_team_name":"待定","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"5","match_type_name":"季军赛","match_type_des":"","group_name":"","host_team_logo_url":null,"guest_team_logo_url":null}]},{"schedule_date":"2022-12-18","schedule_date_format":"12月18日","schedule_week":"周日","schedule_current":"0","schedule_list":[{"team_id":"64","date":"2022-12-18","date_time":"2022-12-18 23:00:00","host_team_id":"0","guest_team_id":"0","host_team_name":"待定","guest_team_name":"待定","host_team_score":"-","guest_team_score":"-","match_status":"1","match_des":"未开赛","match_type":"6","match_type_name":"决赛","match_type_des":"","group_name":"","host_team_logo_url":null,"guest_team_logo_url":null}]}],"ext":{"current_match_type":"1","current_match_type_des":"小组赛"}},"error_code":0}`
 	//getData := []byte(get)
+	saveSnapshot(getData)
+
+	var outcome string
+	result, outcome, err = parseFifa(getData)
+	recordFetch(outcome)
+	return
+}
 
+// parseFifa 解析数据源响应，outcome 为拉取结果，见 FetchSuccess 等
+func parseFifa(getData []byte) (result []*FifaData, outcome string, err error) {
 	fifa := &Fifa{}
 	err = json.Unmarshal(getData, fifa)
 	if err != nil {
+		outcome = FetchDecodeError
 		return
 	}
 
-	if fifa.ErrorCode != 0 {
+	if fifa.ErrorCode != 0 || fifa.Result == nil {
+		outcome = FetchApiError
 		err = errors.New("获取 fifa 数据失败,errCode 不为0, reason:" + fifa.Reason)
+		return
 	}
+	outcome = FetchSuccess
 
 	data := fifa.Result.Data
 	result = data
 	return
 }
 
-// diffLocal 比较差异
-func diffLocal(input []*FifaData) (needPush bool, diffData []*FifaScheduleList, err error) {
+// diffLocal 比较差异，数据异常的行已在 reportMalformed 中报告，不参与比较
+func diffLocal(input []*Match) (needPush bool, diffData []*RaceEvent, err error) {
 	isInit := needInit()
 	if isInit {
 		err = initLocalData(input)
 		return
 	}
 
-	diffData = make([]*FifaScheduleList, 0)
-	for _, schedule := range input {
-		for _, race := range schedule.ScheduleList {
-			// 如果还没到对应比赛时间，跳过检查
-			raceDate, _ := time.ParseInLocation(DateTimBarFormat, race.DateTime, time.Local)
-			if time.Now().Before(raceDate.Add(-1 * time.Second)) {
-				continue
-			}
-			// 判断本地数据是否与在线数据相符
-			localData, ok := localMap[getKey(race)]
-			if !ok || localData != getValue(race) {
-				// 插入变更数据
-				diffData = append(diffData, race)
-				// 更新数据
-				localMap[getKey(race)] = getValue(race)
-				continue
+	diffData = make([]*RaceEvent, 0)
+	for _, match := range input {
+		// 如果还没到对应比赛时间，跳过检查
+		if clock.Now().Before(match.Kickoff.Add(-1 * time.Second)) {
+			continue
+		}
+		// 判断本地数据是否与在线数据相符
+		localData, ok := state.localMap[getKey(match)]
+		if !ok || localData != getValue(match) {
+			// 插入变更数据
+			event := &RaceEvent{Match: match}
+			if ok {
+				event.Prev = parseValue(localData)
 			}
+			diffData = append(diffData, event)
+			// 更新数据
+			state.localMap[getKey(match)] = getValue(match)
+			continue
 		}
 	}
 
@@ -199,21 +311,78 @@ func diffLocal(input []*FifaData) (needPush bool, diffData []*FifaScheduleList,
 	return
 }
 
-func notifyWeCom(input []*FifaScheduleList) (err error) {
+func notifyWeCom(ctx context.Context, input []*RaceEvent) (err error) {
 
-	for _, race := range input {
-		weComPush := makePush(race)
-		weComPushByte, _ := json.Marshal(weComPush)
-		httpPostJson(RobotApi, weComPushByte)
-		log.Printf("推送更新：[%s][%s]%s->%s,[%s]%s-%s",
-			race.Date, race.TeamID, race.HostTeamName, race.GuestTeamName,
-			race.MatchDes, race.HostTeamScore, race.GuestTeamScore)
+	failed := 0
+	push := func(channel *ChannelConfig, msg interface{}) bool {
+		msgByte, _ := json.Marshal(msg)
+		postErr := postWithRetry(ctx, channel.Webhook, msgByte)
+		if postErr != nil {
+			failed++
+			err = fmt.Errorf("%d 条赛况推送失败，已写入死信文件[%s], 最近一次错误: [%s]%s",
+				failed, DeadLetterFile, channel.Name, postErr.Error())
+		}
+		return postErr == nil
+	}
+
+	for _, event := range input {
+		match := event.Match
+		mention := makeGoalMention(event)
+
+		// 比分图按时区缓存，渲染失败不影响卡片推送
+		imagePushMap := make(map[*time.Location]*WeComImagePush)
+		getImagePush := func(loc *time.Location) *WeComImagePush {
+			if !conf.ScoreboardImage {
+				return nil
+			}
+			if imagePush, ok := imagePushMap[loc]; ok {
+				return imagePush
+			}
+			imagePush, renderErr := makeImagePush(ctx, match, loc)
+			if renderErr != nil {
+				log.Printf("渲染比分图失败, err[%s]", renderErr.Error())
+			}
+			imagePushMap[loc] = imagePush
+			return imagePush
+		}
+
+		for _, channel := range conf.getChannels() {
+			if !channel.matchFilter(event) {
+				continue
+			}
+			if !channel.isWeCom() {
+				push(channel, makeEventPayload(event))
+				continue
+			}
+			if channel.inQuietHours(clock.Now()) {
+				enqueueQuiet(channel, match)
+				log.Printf("免打扰中，积压更新[%s]：[%s]%s->%s", channel.Name,
+					match.ID, match.Host.Name, match.Guest.Name)
+				continue
+			}
+			if !push(channel, makePush(match, channel.location())) {
+				continue
+			}
+			hostScore, guestScore := match.scoreText()
+			log.Printf("推送更新[%s]：[%s][%s]%s->%s,[%s]%s-%s", channel.Name,
+				match.Date, match.ID, match.Host.Name, match.Guest.Name,
+				match.StatusDesc, hostScore, guestScore)
+
+			// 进球时 @ 两队球迷
+			if mention != nil {
+				push(channel, mention)
+			}
+			if imagePush := getImagePush(channel.location()); imagePush != nil {
+				push(channel, imagePush)
+			}
+		}
 	}
 
 	return
 }
 
-func makePush(data *FifaScheduleList) (result *WeComPush) {
+// makePush 生成赛况卡片，时间按 loc 展示
+func makePush(match *Match, loc *time.Location) (result *WeComPush) {
 	result = &WeComPush{
 		Msgtype: "template_card",
 		TemplateCard: &TemplateCard{
@@ -243,7 +412,7 @@ func makePush(data *FifaScheduleList) (result *WeComPush) {
 				},
 				{
 					Keyname: "当前时间",
-					Value:   time.Now().Format("2006-01-02 15:04:05"),
+					Value:   clock.Now().In(loc).Format("2006-01-02 15:04:05"),
 				},
 				// 追加一个比赛时间
 			},
@@ -256,20 +425,19 @@ func makePush(data *FifaScheduleList) (result *WeComPush) {
 	}
 
 	card := result.TemplateCard
-	card.MainTitle.Title += fmt.Sprintf("%svs%s", data.HostTeamName, data.GuestTeamName)
-	card.MainTitle.Desc = fmt.Sprintf("【%s】%s%s组", data.MatchTypeName, data.MatchTypeDes, data.GroupName)
-	card.Source.IconURL = data.HostTeamLogoURL
-	card.EmphasisContent.Title = fmt.Sprintf("%s : %s", data.HostTeamScore, data.GuestTeamScore)
-	card.EmphasisContent.Desc = fmt.Sprintf("【%s】",
-		data.MatchDes)
+	hostScore, guestScore := match.scoreText()
+	card.MainTitle.Title += fmt.Sprintf("%svs%s", match.Host.Name, match.Guest.Name)
+	card.MainTitle.Desc = fmt.Sprintf("【%s】%s%s组", match.MatchTypeName, match.Round, match.Group)
+	card.Source.IconURL = match.Host.LogoURL
+	card.EmphasisContent.Title = fmt.Sprintf("%s : %s", hostScore, guestScore)
+	card.EmphasisContent.Desc = fmt.Sprintf("【%s】%s", match.StatusDesc, match.Score.Detail())
 	card.SubTitleText = fmt.Sprintf("👏 预祝和你想得一样!")
 	card.HorizontalContentList = append(card.HorizontalContentList, &HorizontalContentList{
 		Keyname: "开场时间",
-		Value:   data.DateTime,
+		Value:   formatKickoff(match, loc),
 	})
 
-	raceDatetime, _ := time.ParseInLocation(DateTimBarFormat, data.DateTime, time.Local)
-	raceBeginPeriod := time.Now().Sub(raceDatetime).Minutes()
+	raceBeginPeriod := clock.Now().Sub(match.Kickoff).Minutes()
 	if raceBeginPeriod >= 2*60 {
 		card.HorizontalContentList = append(card.HorizontalContentList, &HorizontalContentList{
 			Keyname: "【备注】",
@@ -285,49 +453,65 @@ func makePush(data *FifaScheduleList) (result *WeComPush) {
 }
 
 func needInit() bool {
-	if localMap == nil {
+	if state.localMap == nil {
 		log.Printf("数据需要进行初始化。")
 		return true
 	}
 	return false
 }
 
-func getKey(race *FifaScheduleList) string {
-	return race.TeamID
+func getKey(match *Match) string {
+	return match.ID
 }
 
-func getValue(race *FifaScheduleList) string {
+// ValueSeparator getValue 的分隔符，未开赛时比分为 "-"，不能用 "-" 分隔
+const ValueSeparator = "|"
+
+// getValue 本地记录原样保存数据源的状态与比分字段
+func getValue(match *Match) string {
+	race := match.Raw
 	return strings.Join([]string{
 		race.MatchStatus,
 		race.HostTeamScore,
 		race.GuestTeamScore,
-	}, "-")
+	}, ValueSeparator)
+}
+
+// parseValue 解析 getValue 生成的本地记录，格式不对时返回 nil
+func parseValue(value string) *RaceState {
+	parts := strings.Split(value, ValueSeparator)
+	if len(parts) != 3 {
+		return nil
+	}
+	return &RaceState{Status: parts[0], HostScore: parts[1], GuestScore: parts[2]}
 }
 
-func initLocalData(input []*FifaData) (err error) {
-	localMap = make(map[string]string, 0)
-	if input == nil || len(input) == 0 {
+func initLocalData(input []*Match) (err error) {
+	state.localMap = make(map[string]string, 0)
+	if len(input) == 0 {
 		log.Printf("数据源无数据，完成初始化。")
 		return
 	}
 
-	for _, schedule := range input {
-		for _, race := range schedule.ScheduleList {
-			localMap[getKey(race)] = getValue(race)
-
-			log.Printf("初始化：[%s][%s]%s->%s,[%s]%s-%s",
-				race.Date, race.TeamID, race.HostTeamName, race.GuestTeamName,
-				race.MatchDes, race.HostTeamScore, race.GuestTeamScore)
+	for _, match := range input {
+		state.localMap[getKey(match)] = getValue(match)
 
-		}
+		hostScore, guestScore := match.scoreText()
+		log.Printf("初始化：[%s][%s]%s->%s,[%s]%s-%s",
+			match.Date, match.ID, match.Host.Name, match.Guest.Name,
+			match.StatusDesc, hostScore, guestScore)
 	}
 
 	log.Printf("数据完成初始化。")
 	return
 }
 
-func httpGetJson(url string) (result []byte, err error) {
-	resp, err := http.Get(url)
+func httpGetJson(ctx context.Context, url string) (result []byte, err error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		log.Printf("getErr, url[%s] err[%s]", url, err.Error())
 		return
@@ -345,8 +529,39 @@ func httpGetJson(url string) (result []byte, err error) {
 	return
 }
 
-func httpPostJson(url string, msg []byte) {
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(msg))
+// httpGet 下载文件，非 2xx 视为失败，避免把错误页当作内容缓存
+func httpGet(ctx context.Context, url string) (result []byte, err error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Printf("getErr, url[%s] err[%s]", url, err.Error())
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		err = fmt.Errorf("下载失败, url[%s] code[%d]", url, resp.StatusCode)
+		return
+	}
+	result, err = ioutil.ReadAll(resp.Body)
+	return
+}
+
+// httpPostJson 单次推送，网络异常、非 200 或企业微信 errcode 不为 0 均视为失败，
+// 4xx(429 除外)和不可重试的 errcode 返回 permanentError
+func httpPostJson(ctx context.Context, url string, msg []byte) (err error) {
+	if *dryRun {
+		err = writeDryRun(url, msg)
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(msg))
+	if err != nil {
+		return
+	}
 	req.Header.Set("Content-Type", "application/json")
 
 	client := &http.Client{
@@ -375,6 +590,21 @@ func httpPostJson(url string, msg []byte) {
 	if statusCode != 200 {
 		log.Printf("updateWorldCupRank, doPost, req[%s] code[%d] header[%+v], body[%s]",
 			string(msg), statusCode, hea, string(body))
+		err = fmt.Errorf("推送失败, code[%d]", statusCode)
+		if statusCode >= 400 && statusCode < 500 && statusCode != http.StatusTooManyRequests {
+			err = &permanentError{err: err}
+		}
+		return
+	}
+
+	weComResp := &WeComResp{}
+	if json.Unmarshal(body, weComResp) == nil && weComResp.Errcode != 0 {
+		log.Printf("updateWorldCupRank, doPost, req[%s] body[%s]", string(msg), string(body))
+		err = fmt.Errorf("推送失败, errcode[%d] errmsg[%s]", weComResp.Errcode, weComResp.Errmsg)
+		if !weComRetryableCodes[weComResp.Errcode] {
+			err = &permanentError{err: err}
+		}
+		return
 	}
 	return
 }
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TournamentLocation 数据源中比赛时间所在的时区，聚合数据使用北京时间，可通过 source_timezone 修改
+var TournamentLocation = time.FixedZone("CST", 8*60*60)
+
+// MatchStatus 比赛状态
+type MatchStatus int
+
+const (
+	StatusUnknown    MatchStatus = iota
+	StatusNotStarted             // 未开赛，数据源中为 "1"
+	StatusLive                   // 进行中，数据源中为 "2"
+	StatusFinished               // 完赛，数据源中为 "3"
+)
+
+func (s MatchStatus) String() string {
+	switch s {
+	case StatusNotStarted:
+		return "未开赛"
+	case StatusLive:
+		return "进行中"
+	case StatusFinished:
+		return "完赛"
+	}
+	return "未知"
+}
+
+// parseMatchStatus 解析数据源中的 match_status
+func parseMatchStatus(status string) (MatchStatus, error) {
+	switch status {
+	case "1":
+		return StatusNotStarted, nil
+	case "2":
+		return StatusLive, nil
+	case "3":
+		return StatusFinished, nil
+	}
+	return StatusUnknown, fmt.Errorf("未知的比赛状态[%s]", status)
+}
+
+// Status 比赛状态，无法识别时为 StatusUnknown
+func (r *FifaScheduleList) Status() MatchStatus {
+	status, _ := parseMatchStatus(r.MatchStatus)
+	return status
+}
+
+// Kickoff 开赛时间(TournamentLocation)
+func (r *FifaScheduleList) Kickoff() (result time.Time, err error) {
+	result, err = time.ParseInLocation(DateTimBarFormat, r.DateTime, TournamentLocation)
+	if err != nil {
+		err = fmt.Errorf("无法解析开赛时间[%s]", r.DateTime)
+	}
+	return
+}
+
+// formatKickoff 按 loc 展示开赛时间，与数据源时区不同时附上时区名
+func formatKickoff(match *Match, loc *time.Location) string {
+	kickoff := match.Kickoff.In(loc)
+	if loc == TournamentLocation {
+		return kickoff.Format(DateTimBarFormat)
+	}
+	return kickoff.Format(DateTimBarFormat + " MST")
+}
+
+// Team 参赛球队
+type Team struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	LogoURL string `json:"logo_url"`
+}
+
+// GroupMatchType 小组赛的 match_type
+const GroupMatchType = "1"
+
+// Match 校验并解析后的比赛，拉取后在 processData 中转换一次，之后的推送、积分榜、对阵图等都使用它
+type Match struct {
+	ID string `json:"id"`
+	// Date 比赛日(数据源时区)，即数据源的 schedule_date
+	Date          string      `json:"date"`
+	Kickoff       time.Time   `json:"kickoff"`
+	Status        MatchStatus `json:"status"`
+	StatusDesc    string      `json:"status_desc"`
+	MatchType     string      `json:"match_type"`
+	MatchTypeName string      `json:"match_type_name"`
+	Round         string      `json:"round"`
+	Group         string      `json:"group"`
+	Host          *Team       `json:"host"`
+	Guest         *Team       `json:"guest"`
+	// Score 未开赛时为 nil
+	Score *MatchScore `json:"score"`
+	// Raw 数据源的原始数据，只用于生成本地记录(getValue)
+	Raw *FifaScheduleList `json:"-"`
+}
+
+// isGroupStage 是否为计入小组积分的小组赛
+func (m *Match) isGroupStage() bool {
+	return m.MatchType == GroupMatchType && m.Group != ""
+}
+
+// hasTeam 主客队中是否有 teamID
+func (m *Match) hasTeam(teamID string) bool {
+	return m.Host.ID == teamID || m.Guest.ID == teamID
+}
+
+// scoreText 展示用的比分，未开赛时为 "-"
+func (m *Match) scoreText() (host, guest string) {
+	if m.Score == nil {
+		return "-", "-"
+	}
+	return strconv.Itoa(m.Score.Host), strconv.Itoa(m.Score.Guest)
+}
+
+// stageText 比赛阶段，如 "【小组赛】第1轮A组"
+func (m *Match) stageText() string {
+	stage := fmt.Sprintf("【%s】%s", m.MatchTypeName, m.Round)
+	if m.Group != "" {
+		stage += m.Group + "组"
+	}
+	return stage
+}
+
+// newMatch 校验一行赛程数据
+func newMatch(race *FifaScheduleList) (result *Match, err error) {
+	problems := make([]string, 0)
+	if race.TeamID == "" {
+		problems = append(problems, "缺少比赛 id")
+	}
+
+	kickoff, kickoffErr := race.Kickoff()
+	if kickoffErr != nil {
+		problems = append(problems, kickoffErr.Error())
+	}
+	status, statusErr := parseMatchStatus(race.MatchStatus)
+	if statusErr != nil {
+		problems = append(problems, statusErr.Error())
+	}
+	score, scoreErr := parseMatchScore(race)
+	if scoreErr != nil {
+		problems = append(problems, scoreErr.Error())
+	}
+	if scoreErr == nil && score == nil && (status == StatusLive || status == StatusFinished) {
+		problems = append(problems, fmt.Sprintf("%s的比赛缺少比分", status))
+	}
+
+	if len(problems) > 0 {
+		err = fmt.Errorf("赛程数据异常[%s][%s]%s->%s: %s", race.DateTime, race.TeamID,
+			race.HostTeamName, race.GuestTeamName, strings.Join(problems, "; "))
+		return
+	}
+
+	result = &Match{
+		ID:            race.TeamID,
+		Date:          race.Date,
+		Kickoff:       kickoff,
+		Status:        status,
+		StatusDesc:    race.MatchDes,
+		MatchType:     race.MatchType,
+		MatchTypeName: race.MatchTypeName,
+		Round:         race.MatchTypeDes,
+		Group:         race.GroupName,
+		Host:          &Team{ID: race.HostTeamID, Name: race.HostTeamName, LogoURL: race.HostTeamLogoURL},
+		Guest:         &Team{ID: race.GuestTeamID, Name: race.GuestTeamName, LogoURL: race.GuestTeamLogoURL},
+		Score:         score,
+		Raw:           race,
+	}
+	return
+}
+
+// toMatches 校验全部赛程，异常的行不会出现在结果中，而是在 errs 中逐行报告
+func toMatches(input []*FifaData) (result []*Match, errs []error) {
+	result = make([]*Match, 0)
+	for _, schedule := range input {
+		for _, race := range schedule.ScheduleList {
+			if race == nil {
+				errs = append(errs, errors.New("赛程数据异常: 空的比赛"))
+				continue
+			}
+			match, err := newMatch(race)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if schedule.ScheduleDate != "" {
+				match.Date = schedule.ScheduleDate
+			}
+			result = append(result, match)
+		}
+	}
+	return
+}
+
+var malformedLock sync.Mutex
+
+// reportMalformed 把 toMatches 中新出现的异常行推送到 ErrReportApi
+func reportMalformed(ctx context.Context, errs []error) {
+	malformedLock.Lock()
+	defer malformedLock.Unlock()
+
+	newErrs := make([]string, 0)
+	for _, err := range errs {
+		if state.malformedReported[err.Error()] {
+			continue
+		}
+		state.malformedReported[err.Error()] = true
+		newErrs = append(newErrs, err.Error())
+	}
+	if len(newErrs) == 0 {
+		return
+	}
+
+	err := errors.New(strings.Join(newErrs, "\n"))
+	log.Printf("err[%s]", err.Error())
+	reportErr(ctx, getErrStr(err))
+}
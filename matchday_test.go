@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// testRace A 组的一场比赛，国旗地址留空避免访问外网
+func testRace(id, dateTime, host, guest, status, hostScore, guestScore string) *FifaScheduleList {
+	desc := map[string]string{"1": "未开赛", "2": "进行中", "3": "完赛"}[status]
+	return &FifaScheduleList{
+		TeamID:         id,
+		Date:           dateTime[:10],
+		DateTime:       dateTime,
+		HostTeamID:     host,
+		GuestTeamID:    guest,
+		HostTeamName:   map[string]string{"1": "厄瓜多尔", "2": "荷兰", "3": "卡塔尔", "4": "塞内加尔"}[host],
+		GuestTeamName:  map[string]string{"1": "厄瓜多尔", "2": "荷兰", "3": "卡塔尔", "4": "塞内加尔"}[guest],
+		HostTeamScore:  hostScore,
+		GuestTeamScore: guestScore,
+		MatchStatus:    status,
+		MatchDes:       desc,
+		MatchType:      "1",
+		MatchTypeName:  "小组赛",
+		MatchTypeDes:   "第1轮",
+		GroupName:      "A",
+	}
+}
+
+// TestMatchday 模拟一个比赛日：启动初始化、赛前提醒、开赛进球、数据源报错、
+// 返回非法 json、机器人返回 errcode 后重试、完赛推送积分榜。
+// 开启了比分图，但内置字体不含中文，不推送比分图
+func TestMatchday(t *testing.T) {
+	h := newHarness(t)
+	conf.ScoreboardImage = true
+	conf.ReminderMinutes = []int{10}
+	conf.PushStandings = true
+
+	const opening, second = "2022-11-21 00:00:00", "2022-11-22 00:00:00"
+	notStarted := testRace("1", opening, "3", "1", "1", "-", "-")
+	other := testRace("3", second, "4", "2", "1", "-", "-")
+	h.juhe.script(
+		juheOK(notStarted, other),
+		juheOK(testRace("1", opening, "3", "1", "2", "1", "0"), other),
+		juheErr(10012, "超过每日可允许请求次数"),
+		`{"reason":"查询成功","result":{"data":[`,
+		juheOK(testRace("1", opening, "3", "1", "2", "1", "1"), other),
+		juheOK(testRace("1", opening, "3", "1", "3", "1", "1"), other),
+	)
+
+	// 启动时拉取一次用于初始化，不推送
+	h.tick("2022-11-20 23:00:00")
+	h.expect()
+
+	// 不在检查时间内，不调用数据源，但根据已有赛程发送赛前提醒
+	h.tick("2022-11-20 23:50:00")
+	if n := h.juhe.requestCount(); n != 1 {
+		t.Fatalf("数据源调用 %d 次，期望 1 次", n)
+	}
+	reminder := h.expect("/robot:template_card")
+	if reminder[0].TemplateCard.CardType != "news_notice" {
+		t.Fatalf("赛前提醒卡片类型为 %s", reminder[0].TemplateCard.CardType)
+	}
+
+	// 开赛并进球: 赛况卡片
+	h.tick("2022-11-21 00:15:00")
+	goal := h.expect("/robot:template_card")
+	if title := goal[0].TemplateCard.EmphasisContent.Title; title != "1 : 0" {
+		t.Fatalf("比分为 %s，期望 1 : 0", title)
+	}
+	if !strings.Contains(goal[0].TemplateCard.MainTitle.Title, "卡塔尔vs厄瓜多尔") {
+		t.Fatalf("标题为 %s", goal[0].TemplateCard.MainTitle.Title)
+	}
+
+	// 数据源返回错误码，推送错误通知
+	h.tick("2022-11-21 00:30:00")
+	apiErr := h.expect("/err:text")
+	if !strings.Contains(apiErr[0].Text.Content, "超过每日可允许请求次数") {
+		t.Fatalf("错误通知为 %s", apiErr[0].Text.Content)
+	}
+
+	// 数据源返回非法 json，推送错误通知
+	h.tick("2022-11-21 00:45:00")
+	h.expect("/err:text")
+
+	// 机器人返回 errcode 后重试成功
+	h.wecom.failNext("/robot", 45009)
+	h.tick("2022-11-21 01:00:00")
+	retried := h.expect("/robot:template_card", "/robot:template_card")
+	if retried[0].Errcode != 45009 || retried[1].Errcode != 0 {
+		t.Fatalf("errcode 为 %d、%d，期望先失败后成功", retried[0].Errcode, retried[1].Errcode)
+	}
+	if title := retried[1].TemplateCard.EmphasisContent.Title; title != "1 : 1" {
+		t.Fatalf("比分为 %s，期望 1 : 1", title)
+	}
+
+	// 完赛: 赛况卡片 + 积分榜
+	h.tick("2022-11-21 02:00:00")
+	fullTime := h.expect("/robot:template_card", "/robot:markdown")
+	if !strings.Contains(fullTime[0].TemplateCard.EmphasisContent.Desc, "完赛") {
+		t.Fatalf("比赛状态为 %s", fullTime[0].TemplateCard.EmphasisContent.Desc)
+	}
+	if !strings.Contains(fullTime[1].Markdown.Content, "A组积分榜") {
+		t.Fatalf("积分榜为 %s", fullTime[1].Markdown.Content)
+	}
+
+	if n := h.juhe.requestCount(); n != 6 {
+		t.Fatalf("数据源调用 %d 次，期望 6 次", n)
+	}
+	if letters, err := loadDeadLetters(); err != nil || len(letters) != 0 {
+		t.Fatalf("死信 %d 条, err[%v]，期望没有死信", len(letters), err)
+	}
+}
+
+// TestPausedPush 暂停推送后赛况先积压，错误通知照常发送，即便错误通知与赛况使用同一个机器人；
+// 恢复后补发暂停期间的赛况
+func TestPausedPush(t *testing.T) {
+	h := newHarness(t)
+	ErrReportApi = RobotApi
+
+	const kickoff = "2022-11-21 00:00:00"
+	h.juhe.script(
+		juheOK(testRace("1", kickoff, "3", "1", "1", "-", "-")),
+		juheOK(testRace("1", kickoff, "3", "1", "2", "0", "1")),
+		juheErr(10012, "超过每日可允许请求次数"),
+	)
+
+	h.tick("2022-11-20 23:00:00")
+	h.expect()
+
+	setPushPaused(true)
+	h.tick("2022-11-21 00:15:00")
+	h.expect()
+
+	h.tick("2022-11-21 00:30:00")
+	h.expect("/robot:text")
+
+	setPushPaused(false)
+	h.tick("2022-11-21 00:31:00")
+	messages := h.expect("/robot:template_card")
+	if title := messages[0].TemplateCard.EmphasisContent.Title; title != "0 : 1" {
+		t.Fatalf("补发的比分为 %s，期望 0 : 1", title)
+	}
+}
+
+// TestKickoffAndGoal 开赛与进球在同一次拉取中出现: 未开赛的比分为 "-"，应同时识别为开赛和进球，并 @ 进球方球迷
+func TestKickoffAndGoal(t *testing.T) {
+	h := newHarness(t)
+	conf.Fans = []*FanConfig{{TeamID: "1", UserIDs: []string{"zhangsan"}}}
+	conf.Channels = []*ChannelConfig{
+		{Name: "default", Webhook: RobotApi},
+		{Name: "hook", Type: ChannelWebhook, Webhook: h.wecom.URL + "/hook"},
+	}
+	if err := initFans(); err != nil {
+		t.Fatal(err)
+	}
+
+	const kickoff = "2022-11-21 00:00:00"
+	h.juhe.script(
+		juheOK(testRace("1", kickoff, "3", "1", "1", "-", "-")),
+		juheOK(testRace("1", kickoff, "3", "1", "2", "0", "1")),
+	)
+
+	h.tick("2022-11-20 23:00:00")
+	h.expect()
+
+	h.tick("2022-11-21 00:15:00")
+	messages := h.expect("/robot:template_card", "/robot:text", "/hook:")
+	if title := messages[0].TemplateCard.EmphasisContent.Title; title != "0 : 1" {
+		t.Fatalf("比分为 %s，期望 0 : 1", title)
+	}
+	if mentioned := messages[1].Text.MentionedList; len(mentioned) != 1 || mentioned[0] != "zhangsan" {
+		t.Fatalf("@ 了 %v，期望 [zhangsan]", mentioned)
+	}
+	if events := fmt.Sprint(messages[2].Events); events != fmt.Sprint([]string{EventKickoff, EventGoal}) {
+		t.Fatalf("事件类型为 %s，期望开赛和进球", events)
+	}
+	if prev := messages[2].PrevHostScore; prev == nil || *prev != 0 {
+		t.Fatalf("变更前主队比分为 %v，期望 0", prev)
+	}
+}
+
+// TestQuietCatchUp 免打扰期间完赛，积分榜与赛况一起积压，免打扰结束后先推送赛况汇总再补发积分榜
+func TestQuietCatchUp(t *testing.T) {
+	h := newHarness(t)
+	conf.PushStandings = true
+	conf.Channels = []*ChannelConfig{{
+		Name:       "default",
+		Webhook:    RobotApi,
+		QuietHours: []*QuietHours{{Start: "01:00", End: "08:00"}},
+	}}
+
+	const kickoff = "2022-11-21 00:00:00"
+	h.juhe.script(
+		juheOK(testRace("1", kickoff, "3", "1", "1", "-", "-")),
+		juheOK(testRace("1", kickoff, "3", "1", "3", "0", "2")),
+	)
+
+	h.tick("2022-11-20 23:00:00")
+	h.expect()
+
+	h.tick("2022-11-21 02:00:00")
+	h.expect()
+
+	h.tick("2022-11-21 08:00:00")
+	messages := h.expect("/robot:markdown", "/robot:markdown")
+	if !strings.Contains(messages[0].Markdown.Content, "免打扰期间赛况汇总") {
+		t.Fatalf("汇总为 %s", messages[0].Markdown.Content)
+	}
+	if !strings.Contains(messages[1].Markdown.Content, "A组积分榜") {
+		t.Fatalf("积分榜为 %s", messages[1].Markdown.Content)
+	}
+}
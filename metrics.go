@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// FifaProvider 数据源名称，用作指标的 provider 标签
+const FifaProvider = "juhe"
+
+// FifaDailyQuota 数据源每日免费调用次数，用于估算剩余额度
+const FifaDailyQuota = 50
+
+// 拉取数据的结果，用作指标的 outcome 标签
+const (
+	FetchSuccess     = "success"
+	FetchHttpError   = "http_error"
+	FetchDecodeError = "decode_error"
+	FetchApiError    = "api_error"
+)
+
+// notifyLatencyBuckets 推送耗时直方图的分桶(秒)，含重试等待
+var notifyLatencyBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+
+var (
+	// metricsRegistry 只包含本程序的指标
+	metricsRegistry = prometheus.NewRegistry()
+	metricsFactory  = promauto.With(metricsRegistry)
+
+	fetchTotal = metricsFactory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "fifa", Name: "fetch_total", Help: "数据源拉取次数"}, []string{"provider", "outcome"})
+	quotaRemaining = metricsFactory.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "fifa", Name: "api_quota_remaining", Help: "数据源当日剩余调用次数(按已调用次数估算)"}, []string{"provider"})
+	lastFetchSuccess = metricsFactory.NewGauge(prometheus.GaugeOpts{
+		Namespace: "fifa", Name: "last_success_timestamp_seconds", Help: "最近一次成功拉取数据的时间戳"})
+	diffsTotal = metricsFactory.NewCounter(prometheus.CounterOpts{
+		Namespace: "fifa", Name: "diffs_detected_total", Help: "检测到的比赛变更数"})
+	notifySent = metricsFactory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "fifa", Name: "notify_sent_total", Help: "推送成功次数"}, []string{"channel"})
+	notifyFailed = metricsFactory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "fifa", Name: "notify_failed_total", Help: "推送失败(重试耗尽)次数"}, []string{"channel"})
+	panicsTotal = metricsFactory.NewCounter(prometheus.CounterOpts{
+		Namespace: "fifa", Name: "panics_recovered_total", Help: "GoWithRecovery 捕获的 panic 次数"})
+	notifyLatency = metricsFactory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "fifa", Name: "notify_latency_seconds", Help: "推送耗时(含重试)", Buckets: notifyLatencyBuckets}, []string{"channel"})
+
+	metricsHandler = promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{})
+
+	quotaLock sync.Mutex
+)
+
+// recordFetch 记录一次数据源调用，并估算当日剩余额度
+func recordFetch(outcome string) {
+	fetchTotal.WithLabelValues(FifaProvider, outcome).Inc()
+
+	now := clock.Now()
+	if outcome == FetchSuccess {
+		lastFetchSuccess.Set(float64(now.Unix()))
+		markFetchSuccess(now)
+	}
+
+	quotaLock.Lock()
+	today := now.In(TournamentLocation).Format("2006-01-02")
+	if state.quotaDay != today {
+		state.quotaDay, state.quotaUsed = today, 0
+	}
+	state.quotaUsed++
+	remaining := FifaDailyQuota - state.quotaUsed
+	quotaLock.Unlock()
+
+	if remaining < 0 {
+		remaining = 0
+	}
+	quotaRemaining.WithLabelValues(FifaProvider).Set(float64(remaining))
+}
+
+// recordNotify 记录一次推送(含重试)的结果与耗时
+func recordNotify(channel string, cost time.Duration, err error) {
+	if err != nil {
+		notifyFailed.WithLabelValues(channel).Inc()
+	} else {
+		notifySent.WithLabelValues(channel).Inc()
+	}
+	notifyLatency.WithLabelValues(channel).Observe(cost.Seconds())
+}
+
+// channelNameByURL 根据推送地址找到渠道名
+func channelNameByURL(url string) string {
+	if channel := conf.channelByURL(url); channel != nil {
+		return channel.Name
+	}
+	return "unknown"
+}
+
+// handleMetrics GET /metrics Prometheus 文本格式指标
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	metricsHandler.ServeHTTP(w, r)
+}
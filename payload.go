@@ -0,0 +1,39 @@
+package main
+
+import "time"
+
+// 推送给 webhook 渠道与实时流的消息类型
+const (
+	PayloadEvent     = "event"     // 检测到的比赛变更
+	PayloadSnapshot  = "snapshot"  // 连接建立时回放的当前状态
+	PayloadHeartbeat = "heartbeat" // 心跳
+	PayloadDigest    = "digest"    // 每日日报，只发往 webhook 渠道
+)
+
+// EventPayload 通用的比赛事件消息，webhook 渠道、SSE 与 WebSocket 共用
+type EventPayload struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	// Events 事件类型，见 EventKickoff 等，快照与心跳为空
+	Events []string `json:"events,omitempty"`
+	Match  *Match   `json:"match,omitempty"`
+	// PrevHostScore/PrevGuestScore 变更前的比分
+	PrevHostScore  *int `json:"prev_host_score,omitempty"`
+	PrevGuestScore *int `json:"prev_guest_score,omitempty"`
+	// Content 日报的 markdown 正文
+	Content string `json:"content,omitempty"`
+}
+
+func makeEventPayload(event *RaceEvent) *EventPayload {
+	payload := &EventPayload{
+		Type:   PayloadEvent,
+		Time:   clock.Now(),
+		Events: event.EventTypes(),
+		Match:  event.Match,
+	}
+	if event.Prev != nil {
+		prevHost, prevGuest := event.prevScore()
+		payload.PrevHostScore, payload.PrevGuestScore = &prevHost, &prevGuest
+	}
+	return payload
+}
@@ -34,4 +34,47 @@ type TemplateCard struct {
 	SubTitleText          string                   `json:"sub_title_text"`
 	HorizontalContentList []*HorizontalContentList `json:"horizontal_content_list"`
 	CardAction            *CardAction              `json:"card_action"`
+	ImageTextArea         *ImageTextArea           `json:"image_text_area,omitempty"`
+	CardImage             *CardImage               `json:"card_image,omitempty"`
+}
+type ImageTextArea struct {
+	Type     int    `json:"type"`
+	Title    string `json:"title"`
+	Desc     string `json:"desc"`
+	ImageURL string `json:"image_url"`
+}
+type CardImage struct {
+	URL         string  `json:"url"`
+	AspectRatio float64 `json:"aspect_ratio"`
+}
+type WeComResp struct {
+	Errcode int    `json:"errcode"`
+	Errmsg  string `json:"errmsg"`
+}
+
+type WeComImagePush struct {
+	Msgtype string      `json:"msgtype"`
+	Image   *WeComImage `json:"image"`
+}
+type WeComImage struct {
+	Base64 string `json:"base64"`
+	Md5    string `json:"md5"`
+}
+
+type WeComTextPush struct {
+	Msgtype string     `json:"msgtype"`
+	Text    *WeComText `json:"text"`
+}
+type WeComText struct {
+	Content             string   `json:"content"`
+	MentionedList       []string `json:"mentioned_list,omitempty"`
+	MentionedMobileList []string `json:"mentioned_mobile_list,omitempty"`
+}
+
+type WeComMarkdownPush struct {
+	Msgtype  string         `json:"msgtype"`
+	Markdown *WeComMarkdown `json:"markdown"`
+}
+type WeComMarkdown struct {
+	Content string `json:"content"`
 }
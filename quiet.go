@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QuietHours 免打扰时段，格式 "HH:MM"，End 小于 Start 时表示跨过零点
+type QuietHours struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+var quietLock sync.Mutex
+
+// QuietMessage 免打扰期间积压的一组消息，见 Broadcast
+type QuietMessage struct {
+	Name   string            `json:"name"`
+	Key    string            `json:"key,omitempty"`
+	Expire time.Time         `json:"expire"`
+	Msgs   []json.RawMessage `json:"msgs"`
+}
+
+// contains now 是否落在免打扰时段内
+func (q *QuietHours) contains(now time.Time) bool {
+	start, err := parseClock(q.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(q.End)
+	if err != nil {
+		return false
+	}
+
+	minute := now.Hour()*60 + now.Minute()
+	if start <= end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// parseClock 把 "HH:MM" 转成当天的分钟数
+func parseClock(clock string) (minute int, err error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return
+	}
+	minute = t.Hour()*60 + t.Minute()
+	return
+}
+
+// inQuietHours 渠道当前是否处于免打扰
+func (c *ChannelConfig) inQuietHours(now time.Time) bool {
+	now = now.In(c.location())
+	for _, q := range c.QuietHours {
+		if q.contains(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// enqueueQuiet 免打扰期间的变更先积压，结束后汇总推送
+func enqueueQuiet(channel *ChannelConfig, match *Match) {
+	quietLock.Lock()
+	defer quietLock.Unlock()
+
+	snapshot := *match
+	queue := state.quietQueue[channel.Name]
+	for i, exist := range queue {
+		if exist.ID == match.ID {
+			queue[i] = &snapshot
+			return
+		}
+	}
+	state.quietQueue[channel.Name] = append(queue, &snapshot)
+}
+
+// enqueueQuietMessage 积压一组消息，Key 相同的替换为最新一组
+func enqueueQuietMessage(channel *ChannelConfig, msg *QuietMessage) {
+	quietLock.Lock()
+	defer quietLock.Unlock()
+
+	queue := state.quietMessages[channel.Name]
+	if msg.Key != "" {
+		for i, exist := range queue {
+			if exist.Key == msg.Key {
+				queue = append(queue[:i], queue[i+1:]...)
+				break
+			}
+		}
+	}
+	state.quietMessages[channel.Name] = append(queue, msg)
+}
+
+// flushQuietQueues 免打扰结束的渠道推送一条比赛汇总，再补发积压的其他消息(含暂停期间的推送)，暂停推送时不补发。
+// 推送前先取出积压并释放 quietLock，推送过程中被暂停时消息会重新积压
+func flushQuietQueues(ctx context.Context) {
+	if isPushPaused() {
+		return
+	}
+
+	var err error
+	defer func() {
+		if err != nil {
+			log.Printf("err[%s]", err.Error())
+			reportErr(ctx, getErrStr(err))
+		}
+	}()
+
+	now := clock.Now()
+	for _, channel := range conf.getChannels() {
+		// webhook 渠道不参与免打扰，只会有暂停期间积压的消息
+		if channel.isWeCom() && channel.inQuietHours(now) {
+			continue
+		}
+
+		quietLock.Lock()
+		queue, messages := state.quietQueue[channel.Name], state.quietMessages[channel.Name]
+		delete(state.quietQueue, channel.Name)
+		delete(state.quietMessages, channel.Name)
+		quietLock.Unlock()
+
+		if len(queue) > 0 {
+			msgByte, _ := json.Marshal(makeQuietSummary(queue, channel.location()))
+			if postErr := postMessages(ctx, channel, "免打扰汇总", []json.RawMessage{msgByte}); postErr != nil {
+				err = postErr
+			} else {
+				log.Printf("推送免打扰汇总[%s]：%d 场比赛", channel.Name, len(queue))
+			}
+		}
+
+		for _, msg := range messages {
+			if !msg.Expire.IsZero() && now.After(msg.Expire) {
+				log.Printf("积压的%s已过期，不再补发[%s]", msg.Name, channel.Name)
+				continue
+			}
+			if postErr := postMessages(ctx, channel, msg.Name, msg.Msgs); postErr != nil {
+				err = postErr
+				continue
+			}
+			log.Printf("补发%s[%s]", msg.Name, channel.Name)
+		}
+	}
+}
+
+func makeQuietSummary(queue []*Match, loc *time.Location) (result *WeComMarkdownPush) {
+	lines := []string{"**🌙 免打扰期间赛况汇总**"}
+	for _, match := range queue {
+		hostScore, guestScore := match.scoreText()
+		lines = append(lines, fmt.Sprintf("> %s %s : %s %s <font color=\"comment\">【%s】%s</font>",
+			match.Host.Name, hostScore, guestScore, match.Guest.Name,
+			match.StatusDesc, formatKickoff(match, loc)))
+	}
+
+	result = &WeComMarkdownPush{
+		Msgtype: "markdown",
+		Markdown: &WeComMarkdown{
+			Content: strings.Join(lines, "\n"),
+		},
+	}
+	return
+}
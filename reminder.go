@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LineupMinutes 首发名单一般在开赛前 1 小时公布
+const LineupMinutes = 60
+
+var reminderLock sync.Mutex
+
+// checkReminders 根据已拉取的赛程发送赛前提醒，不额外调用数据源接口
+func checkReminders(ctx context.Context) {
+	var err error
+	defer func() {
+		if err != nil {
+			log.Printf("err[%s]", err.Error())
+			reportErr(ctx, getErrStr(err))
+		}
+	}()
+
+	offsets := append([]int{}, conf.ReminderMinutes...)
+	if len(offsets) == 0 {
+		return
+	}
+	sort.Ints(offsets)
+
+	reminderLock.Lock()
+	defer reminderLock.Unlock()
+
+	now := clock.Now()
+	for _, match := range getLatestMatches() {
+		if match.Status != StatusNotStarted || !now.Before(match.Kickoff) {
+			continue
+		}
+
+		// 只提醒已进入窗口的最小提前量，更大的提前量视为已错过(如程序在开赛前 10 分钟才启动)
+		minutes := 0
+		for _, offset := range offsets {
+			if offset > 0 && !now.Before(match.Kickoff.Add(-time.Duration(offset)*time.Minute)) {
+				minutes = offset
+				break
+			}
+		}
+		if minutes == 0 {
+			continue
+		}
+
+		key := fmt.Sprintf("%s-%d", match.ID, minutes)
+		if state.reminderSent[key] {
+			continue
+		}
+		for _, offset := range offsets {
+			if offset >= minutes {
+				state.reminderSent[fmt.Sprintf("%s-%d", match.ID, offset)] = true
+			}
+		}
+
+		if pushErr := notifyReminder(ctx, &RaceEvent{Match: match, ReminderMinutes: minutes}); pushErr != nil {
+			err = pushErr
+		}
+	}
+}
+
+// notifyReminder 推送赛前提醒，免打扰中的渠道积压到结束后补发，开赛后不再补发
+func notifyReminder(ctx context.Context, event *RaceEvent) (err error) {
+	match := event.Match
+	log.Printf("赛前提醒：[%s][%s]%s->%s,%d分钟后开赛", match.Kickoff.Format(DateTimBarFormat),
+		match.ID, match.Host.Name, match.Guest.Name, event.ReminderMinutes)
+	err = broadcast(ctx, &Broadcast{
+		Name:   "赛前提醒",
+		Key:    "reminder-" + match.ID,
+		Expire: match.Kickoff,
+		Event:  event,
+		MakeMessages: func(channel *ChannelConfig) []interface{} {
+			return []interface{}{makeReminderPush(event, channel.location())}
+		},
+	})
+	return
+}
+
+// makeReminderPush 生成赛前提醒卡片，开赛时间按 loc 展示
+func makeReminderPush(event *RaceEvent, loc *time.Location) (result *WeComPush) {
+	match := event.Match
+	kickoff := formatKickoff(match, loc)
+	result = &WeComPush{
+		Msgtype: "template_card",
+		TemplateCard: &TemplateCard{
+			CardType: "news_notice",
+			Source: &Source{
+				IconURL:   match.Host.LogoURL,
+				Desc:      "世界杯赛前提醒",
+				DescColor: 0,
+			},
+			MainTitle: &MainTitle{
+				Title: fmt.Sprintf("【%d分钟后开赛】%svs%s", event.ReminderMinutes, match.Host.Name, match.Guest.Name),
+				Desc:  fmt.Sprintf("【%s】%s%s组", match.MatchTypeName, match.Round, match.Group),
+			},
+			ImageTextArea: &ImageTextArea{
+				Type:     0,
+				Title:    fmt.Sprintf("%s vs %s", match.Host.Name, match.Guest.Name),
+				Desc:     kickoff,
+				ImageURL: match.Guest.LogoURL,
+			},
+			CardImage: &CardImage{
+				URL:         match.Host.LogoURL,
+				AspectRatio: 1.3,
+			},
+			HorizontalContentList: []*HorizontalContentList{
+				{
+					Keyname: "开场时间",
+					Value:   kickoff,
+				},
+				{
+					Keyname: "比赛阶段",
+					Value:   match.MatchTypeName + match.Round,
+				},
+			},
+			CardAction: &CardAction{
+				Type:  2,
+				URL:   "",
+				AppID: "wxc3435ec8eb22c84f", // 点开卡片跳去腾讯体育看比分
+			},
+		},
+	}
+
+	card := result.TemplateCard
+	if match.Group != "" {
+		card.HorizontalContentList = append(card.HorizontalContentList, &HorizontalContentList{
+			Keyname: "小组",
+			Value:   match.Group + "组",
+		})
+	}
+	if event.ReminderMinutes >= LineupMinutes {
+		card.HorizontalContentList = append(card.HorizontalContentList, &HorizontalContentList{
+			Keyname: "温馨提示",
+			Value:   "首发名单即将公布",
+		})
+	}
+	return
+}
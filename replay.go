@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SnapshotTimeFormat 快照文件名中的录制时间(数据源时区)
+const SnapshotTimeFormat = "20060102-150405"
+
+// saveSnapshot 把数据源响应保存到 conf.SnapshotDir，失败只记录日志，dry-run 时不保存
+func saveSnapshot(data []byte) {
+	if conf.SnapshotDir == "" || *dryRun {
+		return
+	}
+	err := os.MkdirAll(conf.SnapshotDir, 0755)
+	if err == nil {
+		name := clock.Now().In(TournamentLocation).Format(SnapshotTimeFormat) + ".json"
+		err = ioutil.WriteFile(filepath.Join(conf.SnapshotDir, name), data, 0644)
+	}
+	if err != nil {
+		log.Printf("保存快照失败, err[%s]", err.Error())
+	}
+}
+
+// snapshotTime 快照的录制时间，文件名无法解析时使用修改时间
+func snapshotTime(info os.FileInfo) time.Time {
+	name := strings.TrimSuffix(info.Name(), filepath.Ext(info.Name()))
+	if t, err := time.ParseInLocation(SnapshotTimeFormat, name, TournamentLocation); err == nil {
+		return t
+	}
+	return info.ModTime()
+}
+
+// replaySnapshots 按录制时间顺序把快照逐个送入推送流程，时钟同步拨到录制时间
+// 第一个快照用于初始化本地状态，与正常启动一致
+func replaySnapshots(ctx context.Context, dir string) (err error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	files := make([]os.FileInfo, 0, len(infos))
+	for _, info := range infos {
+		if !info.IsDir() && filepath.Ext(info.Name()) == ".json" {
+			files = append(files, info)
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return snapshotTime(files[i]).Before(snapshotTime(files[j]))
+	})
+	fakeClock := NewFakeClock(time.Now())
+	prevClock := setClock(fakeClock)
+	defer setClock(prevClock)
+
+	for _, info := range files {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+		at := snapshotTime(info)
+		fakeClock.Set(at)
+
+		data, readErr := ioutil.ReadFile(filepath.Join(dir, info.Name()))
+		if readErr != nil {
+			return readErr
+		}
+		fifa, _, parseErr := parseFifa(data)
+		if parseErr != nil {
+			log.Printf("跳过快照[%s], err[%s]", info.Name(), parseErr.Error())
+			continue
+		}
+		log.Printf("重放快照[%s]，时间[%s]", info.Name(), at.In(TournamentLocation).Format(DateTimBarFormat))
+
+		flushQuietQueues(ctx)
+		replayData(ctx, fifa)
+		checkReminders(ctx)
+		checkDigest(ctx)
+		checkScenarios(ctx)
+	}
+	log.Printf("重放完成，共 %d 个快照。", len(files))
+	return
+}
+
+// replayData 同 refreshData，但数据来自快照
+func replayData(ctx context.Context, fifa []*FifaData) {
+	refreshLock.Lock()
+	defer refreshLock.Unlock()
+
+	if err := processData(ctx, fifa); err != nil {
+		log.Printf("err[%s]", err.Error())
+		reportErr(ctx, getErrStr(err))
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ScenarioMaxGoals 枚举出线形势时每队每场最多枚举的进球数
+const ScenarioMaxGoals = 4
+
+const (
+	resultWin  = "win"
+	resultDraw = "draw"
+	resultLoss = "loss"
+)
+
+// TeamScenario 一支球队在所有枚举结果下的出线情况
+type TeamScenario struct {
+	TeamID   string
+	TeamName string
+	Points   int
+	Total    int
+	Qualify  int
+	// map[resultWin/resultDraw/resultLoss] = [出线次数, 总次数]
+	ByResult map[string][2]int
+}
+
+var scenarioLock sync.Mutex
+
+// checkScenarios 小组最后一轮开赛前 conf.ScenarioMinutes 分钟推送出线形势
+func checkScenarios(ctx context.Context) {
+	var err error
+	defer func() {
+		if err != nil {
+			log.Printf("err[%s]", err.Error())
+			reportErr(ctx, getErrStr(err))
+		}
+	}()
+
+	if conf.ScenarioMinutes <= 0 {
+		return
+	}
+
+	matches := getLatestMatches()
+
+	scenarioLock.Lock()
+	defer scenarioLock.Unlock()
+
+	now := clock.Now()
+	for _, match := range matches {
+		if !match.isGroupStage() || match.Status != StatusNotStarted ||
+			state.scenarioSent[match.Group] || !isFinalGroupRound(match, matches) {
+			continue
+		}
+		if now.Before(match.Kickoff.Add(-time.Duration(conf.ScenarioMinutes) * time.Minute)) {
+			continue
+		}
+		state.scenarioSent[match.Group] = true
+
+		scenarios := computeScenarios(matches, match.Group)
+		if len(scenarios) == 0 {
+			continue
+		}
+		pushErr := broadcast(ctx, &Broadcast{
+			Name: match.Group + "组出线形势",
+			Key:  "scenario-" + match.Group,
+			// 开赛后形势已变，不再补发
+			Expire:   match.Kickoff,
+			Event:    &RaceEvent{Match: match, ReminderMinutes: conf.ScenarioMinutes},
+			Messages: []interface{}{makeScenarioPush(match.Group, scenarios)},
+		})
+		if pushErr != nil {
+			err = pushErr
+		}
+	}
+}
+
+// computeScenarios 枚举小组剩余比赛的所有比分(每队 0~ScenarioMaxGoals 球)，统计每队的出线情况
+func computeScenarios(matches []*Match, group string) (result []*TeamScenario) {
+	groupRaces := make([]*Match, 0)
+	remain := make([]int, 0)
+	for _, match := range matches {
+		if match.MatchType != GroupMatchType || match.Group != group {
+			continue
+		}
+		if match.Status != StatusFinished {
+			remain = append(remain, len(groupRaces))
+			copied := *match
+			copied.Status = StatusFinished
+			copied.Score = &MatchScore{}
+			match = &copied
+		}
+		groupRaces = append(groupRaces, match)
+	}
+	if len(remain) == 0 {
+		return
+	}
+
+	scenarioMap := make(map[string]*TeamScenario)
+	for _, row := range standingsFromMatches(matches, false)[group] {
+		scenarioMap[row.TeamID] = &TeamScenario{
+			TeamID:   row.TeamID,
+			TeamName: row.TeamName,
+			Points:   row.Points,
+			ByResult: make(map[string][2]int),
+		}
+	}
+
+	var enumerate func(i int)
+	enumerate = func(i int) {
+		if i < len(remain) {
+			score := groupRaces[remain[i]].Score
+			for host := 0; host <= ScenarioMaxGoals; host++ {
+				for guest := 0; guest <= ScenarioMaxGoals; guest++ {
+					score.Host, score.Guest = host, guest
+					enumerate(i + 1)
+				}
+			}
+			return
+		}
+
+		rows := standingsFromMatches(groupRaces, false)[group]
+		for pos, row := range rows {
+			scenario, ok := scenarioMap[row.TeamID]
+			if !ok {
+				continue
+			}
+			qualify := pos < 2
+			scenario.Total++
+			if qualify {
+				scenario.Qualify++
+			}
+
+			for _, idx := range remain {
+				race := groupRaces[idx]
+				if !race.hasTeam(row.TeamID) {
+					continue
+				}
+				stat := scenario.ByResult[teamResult(race, row.TeamID)]
+				stat[1]++
+				if qualify {
+					stat[0]++
+				}
+				scenario.ByResult[teamResult(race, row.TeamID)] = stat
+			}
+		}
+	}
+	enumerate(0)
+
+	for _, scenario := range scenarioMap {
+		result = append(result, scenario)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Points != result[j].Points {
+			return result[i].Points > result[j].Points
+		}
+		return result[i].TeamID < result[j].TeamID
+	})
+	return
+}
+
+// teamResult 某队在这场比赛中的胜平负
+func teamResult(match *Match, teamID string) string {
+	host, guest := match.Score.Host, match.Score.Guest
+	if match.Guest.ID == teamID {
+		host, guest = guest, host
+	}
+	switch {
+	case host > guest:
+		return resultWin
+	case host == guest:
+		return resultDraw
+	}
+	return resultLoss
+}
+
+// Summary 每支球队"需要什么"的文字描述
+func (s *TeamScenario) Summary() string {
+	switch {
+	case s.Total == 0:
+		return "无剩余比赛"
+	case s.Qualify == s.Total:
+		return "已确定出线"
+	case s.Qualify == 0:
+		return "已无出线可能"
+	}
+
+	parts := make([]string, 0, 3)
+	for _, item := range []struct{ key, name string }{
+		{resultWin, "赢球"}, {resultDraw, "打平"}, {resultLoss, "输球"},
+	} {
+		stat, ok := s.ByResult[item.key]
+		if !ok || stat[1] == 0 {
+			continue
+		}
+		switch {
+		case stat[0] == stat[1]:
+			parts = append(parts, item.name+"即出线")
+		case stat[0] == 0:
+			parts = append(parts, item.name+"出局")
+		default:
+			parts = append(parts, fmt.Sprintf("%s需看另一场及净胜球(%d%%)", item.name, stat[0]*100/stat[1]))
+		}
+	}
+	return strings.Join(parts, "；")
+}
+
+func makeScenarioPush(group string, scenarios []*TeamScenario) (result *WeComMarkdownPush) {
+	lines := []string{fmt.Sprintf("**🧮 %s组最后一轮出线形势**", group)}
+	for _, scenario := range scenarios {
+		lines = append(lines, fmt.Sprintf("> **%s**(%d分)：%s", scenario.TeamName, scenario.Points, scenario.Summary()))
+	}
+	lines = append(lines, fmt.Sprintf("<font color=\"comment\">按每队每场 0~%d 球枚举，百分比为对应结果下的出线比例</font>", ScenarioMaxGoals))
+
+	result = &WeComMarkdownPush{
+		Msgtype:  "markdown",
+		Markdown: &WeComMarkdown{Content: strings.Join(lines, "\n")},
+	}
+	return
+}
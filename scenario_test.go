@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestComputeScenarios A 组前两轮后：1 号 6 分已确定出线，3 号 3 分赢球即出线、输球出局，打平要看另一场
+func TestComputeScenarios(t *testing.T) {
+	races := []*FifaScheduleList{
+		testRace("1", "2022-11-21 00:00:00", "1", "3", "3", "1", "0"),
+		testRace("2", "2022-11-21 03:00:00", "2", "4", "3", "0", "0"),
+		testRace("3", "2022-11-25 00:00:00", "1", "4", "3", "1", "0"),
+		testRace("4", "2022-11-25 03:00:00", "3", "2", "3", "1", "0"),
+		testRace("5", "2022-11-29 23:00:00", "1", "2", "1", "-", "-"),
+		testRace("6", "2022-11-29 23:00:00", "3", "4", "1", "-", "-"),
+	}
+	matches := make([]*Match, 0, len(races))
+	for _, race := range races {
+		match, err := newMatch(race)
+		if err != nil {
+			t.Fatal(err)
+		}
+		matches = append(matches, match)
+	}
+
+	scenarios := computeScenarios(matches, "A")
+	order := make([]string, 0, len(scenarios))
+	summaries := make(map[string]string)
+	for _, scenario := range scenarios {
+		order = append(order, scenario.TeamID)
+		summaries[scenario.TeamID] = scenario.Summary()
+	}
+	if strings.Join(order, ",") != "1,3,2,4" {
+		t.Fatalf("排列顺序为 %v，应按积分排列", order)
+	}
+	if summaries["1"] != "已确定出线" {
+		t.Errorf("1 号的形势为 %q", summaries["1"])
+	}
+	if s := summaries["3"]; !strings.HasPrefix(s, "赢球即出线；打平需看另一场及净胜球") || !strings.HasSuffix(s, "输球出局") {
+		t.Errorf("3 号的形势为 %q", s)
+	}
+
+	// 枚举只改动副本
+	if matches[4].Status != StatusNotStarted || matches[4].Score != nil {
+		t.Errorf("枚举改动了未开赛的比赛 %+v", matches[4])
+	}
+
+	// 全部完赛后不再计算
+	for _, match := range matches[4:] {
+		match.Status, match.Score = StatusFinished, &MatchScore{Host: 1, Guest: 0}
+	}
+	if scenarios = computeScenarios(matches, "A"); len(scenarios) != 0 {
+		t.Errorf("全部完赛后仍计算了 %d 支球队的形势", len(scenarios))
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MatchScore 解析后的比分，区分加时与点球
+type MatchScore struct {
+	// Host/Guest 最终比分(含加时，不含点球)
+	Host  int `json:"host"`
+	Guest int `json:"guest"`
+	// ExtraTime 比赛状态描述含"加时"或有点球比分时为 true，聚合数据不提供 90 分钟比分
+	ExtraTime    bool `json:"extra_time"`
+	PenaltyHost  int  `json:"penalty_host"`
+	PenaltyGuest int  `json:"penalty_guest"`
+	Penalties    bool `json:"penalties"`
+	// WinnerTeamID 胜者，平局或未完赛时为空
+	WinnerTeamID string `json:"winner_team_id"`
+}
+
+// scoreWithPenaltyRegexp 兼容 "3(4)"、"3 (4)" 这种把点球比分写在括号里的数据源
+var scoreWithPenaltyRegexp = regexp.MustCompile(`^\s*(\d+)\s*[(（]\s*(\d+)\s*[)）]\s*$`)
+
+// parseMatchScore 解析比分，未开赛("-" 或空)时返回 nil
+func parseMatchScore(race *FifaScheduleList) (result *MatchScore, err error) {
+	host, hostPen, hostHasPen, err := parseScoreField(race.HostTeamScore)
+	if err != nil {
+		return
+	}
+	guest, guestPen, guestHasPen, err := parseScoreField(race.GuestTeamScore)
+	if err != nil {
+		return
+	}
+	if host < 0 || guest < 0 {
+		return
+	}
+
+	result = &MatchScore{
+		Host:      host,
+		Guest:     guest,
+		ExtraTime: strings.Contains(race.MatchDes, "加时"),
+	}
+	if hostHasPen && guestHasPen {
+		result.Penalties = true
+		result.ExtraTime = true
+		result.PenaltyHost, result.PenaltyGuest = hostPen, guestPen
+	}
+
+	if race.Status() != StatusFinished {
+		return
+	}
+	switch {
+	case host > guest:
+		result.WinnerTeamID = race.HostTeamID
+	case guest > host:
+		result.WinnerTeamID = race.GuestTeamID
+	case result.Penalties && result.PenaltyHost > result.PenaltyGuest:
+		result.WinnerTeamID = race.HostTeamID
+	case result.Penalties && result.PenaltyGuest > result.PenaltyHost:
+		result.WinnerTeamID = race.GuestTeamID
+	}
+	return
+}
+
+// parseScoreField 解析单队比分，未开赛时 score 为 -1
+func parseScoreField(field string) (score, penalty int, hasPenalty bool, err error) {
+	field = strings.TrimSpace(field)
+	if field == "" || field == "-" {
+		score = -1
+		return
+	}
+	if match := scoreWithPenaltyRegexp.FindStringSubmatch(field); match != nil {
+		score, _ = strconv.Atoi(match[1])
+		penalty, _ = strconv.Atoi(match[2])
+		hasPenalty = true
+		return
+	}
+	score, err = strconv.Atoi(field)
+	if err != nil {
+		err = fmt.Errorf("无法解析比分[%s]", field)
+	}
+	return
+}
+
+// Detail 加时、点球的补充说明，如 "(加时, 点球 4 : 2)"，没有时为空
+func (s *MatchScore) Detail() string {
+	if s == nil || !s.ExtraTime {
+		return ""
+	}
+	parts := []string{"加时"}
+	if s.Penalties {
+		parts = append(parts, fmt.Sprintf("点球 %d : %d", s.PenaltyHost, s.PenaltyGuest))
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
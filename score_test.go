@@ -0,0 +1,52 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMatchScore(t *testing.T) {
+	cases := []struct {
+		name       string
+		status     string
+		desc       string
+		host       string
+		guest      string
+		want       *MatchScore
+		wantErr    bool
+		wantDetail string
+	}{
+		{name: "未开赛", status: "1", desc: "未开赛", host: "-", guest: "-"},
+		{name: "常规时间", status: "3", desc: "完赛", host: "2", guest: "1",
+			want: &MatchScore{Host: 2, Guest: 1, WinnerTeamID: "3"}},
+		{name: "进行中不判胜负", status: "2", desc: "进行中", host: "0", guest: "1",
+			want: &MatchScore{Host: 0, Guest: 1}},
+		{name: "加时", status: "3", desc: "加时完赛", host: "1", guest: "2",
+			want: &MatchScore{Host: 1, Guest: 2, ExtraTime: true, WinnerTeamID: "1"}, wantDetail: "(加时)"},
+		{name: "点球", status: "3", desc: "完赛", host: "3(4)", guest: "3(2)",
+			want: &MatchScore{Host: 3, Guest: 3, ExtraTime: true, PenaltyHost: 4, PenaltyGuest: 2,
+				Penalties: true, WinnerTeamID: "3"}, wantDetail: "(加时, 点球 4 : 2)"},
+		{name: "全角括号与空格", status: "3", desc: "完赛", host: "1 （3）", guest: "1（4）",
+			want: &MatchScore{Host: 1, Guest: 1, ExtraTime: true, PenaltyHost: 3, PenaltyGuest: 4,
+				Penalties: true, WinnerTeamID: "1"}, wantDetail: "(加时, 点球 3 : 4)"},
+		{name: "只有一方有点球比分", status: "3", desc: "完赛", host: "3(4)", guest: "3",
+			want: &MatchScore{Host: 3, Guest: 3}},
+		{name: "无法解析", status: "3", desc: "完赛", host: "x", guest: "1", wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			race := testRace("9", "2022-12-18 23:00:00", "3", "1", c.status, c.host, c.guest)
+			race.MatchDes = c.desc
+			got, err := parseMatchScore(race)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("err[%v]，wantErr %v", err, c.wantErr)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("比分为 %+v，期望 %+v", got, c.want)
+			}
+			if detail := got.Detail(); detail != c.wantDetail {
+				t.Fatalf("说明为 %q，期望 %q", detail, c.wantDetail)
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"unicode"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// LogoCacheDir 国旗图片的本地缓存目录，首次下载后离线也能渲染
+const LogoCacheDir = "logo_cache"
+
+const (
+	scoreboardWidth  = 800
+	scoreboardHeight = 400
+	scoreboardLogo   = 128
+)
+
+var (
+	scoreboardBg     = color.RGBA{R: 0x8a, G: 0x15, B: 0x38, A: 0xff} // 卡塔尔酒红
+	scoreboardPanel  = color.RGBA{R: 0x5c, G: 0x0e, B: 0x25, A: 0xff}
+	scoreboardWhite  = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	scoreboardSilver = color.RGBA{R: 0xd9, G: 0xd9, B: 0xd9, A: 0xff}
+	scoreboardGold   = color.RGBA{R: 0xf2, G: 0xc2, B: 0x4b, A: 0xff}
+)
+
+var (
+	scoreboardFontLock sync.Mutex
+	// scoreboardFonts map[字体文件] = 解析后的字体，空串为内置字体
+	scoreboardFonts = make(map[string]*opentype.Font)
+	logoCacheLock   sync.Mutex
+)
+
+// makeImagePush 生成比分图片消息
+func makeImagePush(ctx context.Context, match *Match, loc *time.Location) (result *WeComImagePush, err error) {
+	pngByte, err := renderScoreboard(ctx, match, loc)
+	if err != nil {
+		return
+	}
+
+	result = makeImageMessage(pngByte)
+	return
+}
+
+// makeImageMessage 把 png 包装成企业微信图片消息
+func makeImageMessage(pngByte []byte) *WeComImagePush {
+	sum := md5.Sum(pngByte)
+	return &WeComImagePush{
+		Msgtype: "image",
+		Image: &WeComImage{
+			Base64: base64.StdEncoding.EncodeToString(pngByte),
+			Md5:    hex.EncodeToString(sum[:]),
+		},
+	}
+}
+
+// renderScoreboard 渲染比分图: 两队国旗、队名、比分、比赛分钟数、阶段，开赛时间按 loc 展示
+func renderScoreboard(ctx context.Context, match *Match, loc *time.Location) (result []byte, err error) {
+	fnt, err := loadScoreboardFont()
+	if err != nil {
+		return
+	}
+
+	// 顶部: 阶段
+	stage := match.MatchTypeName
+	if match.Round != "" {
+		stage += " " + match.Round
+	}
+	if match.Group != "" {
+		stage += " " + match.Group + "组"
+	}
+
+	// 字体缺字时画出来是一排方块，不如不发
+	err = checkFontGlyphs(fnt, stage, match.Host.Name, match.Guest.Name, match.StatusDesc)
+	if err != nil {
+		return
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, scoreboardWidth, scoreboardHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(scoreboardBg), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, scoreboardWidth, 64), image.NewUniform(scoreboardPanel), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, scoreboardHeight-56, scoreboardWidth, scoreboardHeight),
+		image.NewUniform(scoreboardPanel), image.Point{}, draw.Src)
+
+	drawText(img, fnt, 28, stage, scoreboardWidth/2, 42, alignCenter, scoreboardGold)
+
+	// 国旗
+	hostX, guestX := scoreboardWidth/4, scoreboardWidth*3/4
+	logoTop := 88
+	drawLogo(ctx, img, match.Host.LogoURL, hostX-scoreboardLogo/2, logoTop)
+	drawLogo(ctx, img, match.Guest.LogoURL, guestX-scoreboardLogo/2, logoTop)
+
+	// 队名
+	nameY := logoTop + scoreboardLogo + 44
+	drawText(img, fnt, 32, match.Host.Name, hostX, nameY, alignCenter, scoreboardWhite)
+	drawText(img, fnt, 32, match.Guest.Name, guestX, nameY, alignCenter, scoreboardWhite)
+
+	// 比分
+	hostScore, guestScore := match.scoreText()
+	score := fmt.Sprintf("%s : %s", hostScore, guestScore)
+	status := match.StatusDesc
+	if detail := match.Score.Detail(); detail != "" {
+		status += " " + detail
+	}
+	drawText(img, fnt, 72, score, scoreboardWidth/2, logoTop+scoreboardLogo/2+26, alignCenter, scoreboardWhite)
+	drawText(img, fnt, 24, status, scoreboardWidth/2, nameY+50, alignCenter, scoreboardSilver)
+
+	// 底部: 开场时间与比赛分钟数
+	footer := "Kick-off " + formatKickoff(match, loc)
+	if match.Status == StatusLive {
+		footer += fmt.Sprintf("   %d'", int(clock.Now().Sub(match.Kickoff).Minutes()))
+	}
+	drawText(img, fnt, 22, footer, scoreboardWidth/2, scoreboardHeight-20, alignCenter, scoreboardSilver)
+
+	buf := &bytes.Buffer{}
+	err = png.Encode(buf, img)
+	if err != nil {
+		return
+	}
+	result = buf.Bytes()
+	return
+}
+
+// loadScoreboardFont 加载 conf.ScoreboardFont 指定的字体，为空时使用内置的 Go 字体
+func loadScoreboardFont() (result *opentype.Font, err error) {
+	path := conf.ScoreboardFont
+
+	scoreboardFontLock.Lock()
+	defer scoreboardFontLock.Unlock()
+	if f, ok := scoreboardFonts[path]; ok {
+		result = f
+		return
+	}
+
+	fontByte := gobold.TTF
+	if path != "" {
+		fontByte, err = ioutil.ReadFile(path)
+		if err != nil {
+			return
+		}
+	}
+
+	result, err = opentype.Parse(fontByte)
+	if err != nil {
+		return
+	}
+	scoreboardFonts[path] = result
+	return
+}
+
+// checkScoreboardFont 检查配置的字体能否渲染示例比赛的阶段、队名与状态
+func checkScoreboardFont() (err error) {
+	fnt, err := loadScoreboardFont()
+	if err != nil {
+		return
+	}
+	err = checkFontGlyphs(fnt, sampleRace.MatchTypeName, sampleRace.MatchTypeDes, sampleRace.GroupName+"组",
+		sampleRace.HostTeamName, sampleRace.GuestTeamName, sampleRace.MatchDes)
+	return
+}
+
+// checkFontGlyphs 检查字体是否包含 texts 中所有字符的字形，内置字体不含中文
+func checkFontGlyphs(fnt *opentype.Font, texts ...string) (err error) {
+	buf := &sfnt.Buffer{}
+	for _, text := range texts {
+		for _, r := range text {
+			if unicode.IsSpace(r) {
+				continue
+			}
+			index, glyphErr := fnt.GlyphIndex(buf, r)
+			if glyphErr != nil {
+				return glyphErr
+			}
+			if index == 0 {
+				return fmt.Errorf("字体缺少[%s]的字形，请在 scoreboard_font 中配置中文字体", string(r))
+			}
+		}
+	}
+	return
+}
+
+// textAlign 文字相对于 x 的对齐方式
+type textAlign int
+
+const (
+	alignLeft textAlign = iota
+	alignCenter
+)
+
+// drawText 以 baseline 为基线绘制文字，align 为 alignLeft 时 x 是起点，为 alignCenter 时 x 是中心
+func drawText(dst draw.Image, fnt *opentype.Font, size float64, text string, x, baseline int, align textAlign, col color.Color) {
+	if text == "" {
+		return
+	}
+
+	face, err := opentype.NewFace(fnt, &opentype.FaceOptions{
+		Size:    size,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		log.Printf("创建字体失败, err[%s]", err.Error())
+		return
+	}
+	defer face.Close()
+
+	drawer := &font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(col),
+		Face: face,
+		Dot:  fixed.P(x, baseline),
+	}
+	if align == alignCenter {
+		drawer.Dot.X -= drawer.MeasureString(text) / 2
+	}
+	drawer.DrawString(text)
+}
+
+// drawLogo 把国旗缩放后画在 (x, y)，获取失败时画一个占位方块
+func drawLogo(ctx context.Context, dst draw.Image, url string, x, y int) {
+	rect := image.Rect(x, y, x+scoreboardLogo, y+scoreboardLogo)
+
+	logo, err := loadLogo(ctx, url)
+	if err != nil {
+		log.Printf("加载国旗失败, url[%s] err[%s]", url, err.Error())
+		draw.Draw(dst, rect, image.NewUniform(scoreboardPanel), image.Point{}, draw.Src)
+		return
+	}
+
+	draw.CatmullRom.Scale(dst, rect, logo, logo.Bounds(), draw.Over, nil)
+}
+
+// loadLogo 优先读取本地缓存，没有再下载并写入缓存
+func loadLogo(ctx context.Context, url string) (result image.Image, err error) {
+	if url == "" {
+		err = errors.New("国旗地址为空")
+		return
+	}
+
+	logoCacheLock.Lock()
+	defer logoCacheLock.Unlock()
+
+	sum := md5.Sum([]byte(url))
+	cacheFile := filepath.Join(LogoCacheDir, hex.EncodeToString(sum[:])+filepath.Ext(url))
+
+	data, err := ioutil.ReadFile(cacheFile)
+	if err != nil {
+		data, err = httpGet(ctx, url)
+		if err != nil {
+			return
+		}
+		if _, _, err = image.Decode(bytes.NewReader(data)); err != nil {
+			return
+		}
+
+		if mkErr := os.MkdirAll(LogoCacheDir, 0755); mkErr != nil {
+			log.Printf("创建国旗缓存目录失败, err[%s]", mkErr.Error())
+		} else if writeErr := ioutil.WriteFile(cacheFile, data, 0644); writeErr != nil {
+			log.Printf("写入国旗缓存失败, err[%s]", writeErr.Error())
+		}
+	}
+
+	result, _, err = image.Decode(bytes.NewReader(data))
+	return
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestLoadLogoNotFound 国旗地址返回 404 时不能把错误页当作图片
+func TestLoadLogoNotFound(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	if _, err := loadLogo(context.Background(), server.URL+"/missing.png"); err == nil {
+		t.Fatal("404 应返回错误")
+	}
+	if _, err := httpGet(context.Background(), server.URL); err == nil {
+		t.Fatal("httpGet 遇到 404 应返回错误")
+	}
+}
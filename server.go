@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+)
+
+// HttpShutdownTimeout 退出时等待 http 请求完成的最长时间
+const HttpShutdownTimeout = 5 * time.Second
+
+// startHttpServer 启动内置 http 服务，conf.HttpAddr 为空时不启动并返回 nil
+func startHttpServer() (server *http.Server) {
+	if conf.HttpAddr == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/fans", handleFans)
+	mux.HandleFunc("/api/matches", handleMatches)
+	mux.HandleFunc("/api/matches/", handleMatches)
+	mux.HandleFunc("/api/groups/", handleGroups)
+	mux.HandleFunc("/api/today", handleToday)
+	mux.HandleFunc("/api/stream", handleSSE)
+	mux.HandleFunc("/api/ws", handleWebSocket)
+	mux.HandleFunc("/api/standings", handleStandings)
+	mux.HandleFunc("/api/bracket", handleBracket)
+	mux.HandleFunc("/api/bracket.svg", handleBracket)
+	mux.HandleFunc("/api/admin/refresh", withAdminAuth(handleAdminRefresh))
+	mux.HandleFunc("/api/admin/resend", withAdminAuth(handleAdminResend))
+	mux.HandleFunc("/api/admin/state", withAdminAuth(handleAdminState))
+	mux.HandleFunc("/api/admin/pause", withAdminAuth(handleAdminPause))
+	mux.HandleFunc("/api/admin/resume", withAdminAuth(handleAdminPause))
+	mux.HandleFunc("/metrics", handleMetrics)
+	mux.HandleFunc("/healthz", handleHealthz)
+	mux.HandleFunc("/readyz", handleReadyz)
+	mux.HandleFunc("/", handleIndex)
+
+	server = &http.Server{Addr: conf.HttpAddr, Handler: mux}
+	server.RegisterOnShutdown(hub.shutdown)
+	GoWithRecovery(func() {
+		log.Printf("http 服务监听[%s]", conf.HttpAddr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("http 服务退出, err[%s]", err.Error())
+		}
+	})
+	return
+}
+
+// shutdownHttpServer 停止接收新请求，等待进行中的请求完成，
+// 实时流(SSE、WebSocket)收到 hub.shutdown 通知后主动断开
+func shutdownHttpServer(server *http.Server) {
+	if server == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), HttpShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		_ = server.Close()
+	}
+}
+
+func writeJson(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+func writeJsonErr(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
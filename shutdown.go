@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// ShutdownTimeout 收到退出信号后等待当前检查完成的最长时间，超时后取消进行中的拉取与推送(未送达的写入死信)
+const ShutdownTimeout = 30 * time.Second
+
+// workCtx 常驻运行时拉取与推送使用的 context，管理接口触发的异步刷新也使用它
+var workCtx = context.Background()
+
+// newWorkContext 拉取与推送使用的 context，ctx 取消后不立即取消，而是再等 ShutdownTimeout，
+// 让当前检查有机会做完
+func newWorkContext(ctx context.Context) (work context.Context, cancel context.CancelFunc) {
+	work, cancel = context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-work.Done():
+			return
+		}
+		timer := time.NewTimer(ShutdownTimeout)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			cancel()
+		case <-work.Done():
+		}
+	}()
+	return
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// StandingRow 小组积分榜的一行
+type StandingRow struct {
+	TeamID       string `json:"team_id"`
+	TeamName     string `json:"team_name"`
+	Played       int    `json:"played"`
+	Won          int    `json:"won"`
+	Drawn        int    `json:"drawn"`
+	Lost         int    `json:"lost"`
+	GoalsFor     int    `json:"goals_for"`
+	GoalsAgainst int    `json:"goals_against"`
+	Points       int    `json:"points"`
+	// FairPlay 公平竞赛分(黄红牌扣分，越大越好)，数据源没有牌数据，目前恒为 0
+	FairPlay int `json:"fair_play"`
+	// Unresolved 与相邻球队按以上规则都无法区分，实际需抽签，暂按 TeamID 排列
+	Unresolved bool `json:"unresolved,omitempty"`
+}
+
+// groupResult 一场计入积分榜的小组赛
+type groupResult struct {
+	HostTeamID  string
+	GuestTeamID string
+	HostScore   int
+	GuestScore  int
+}
+
+func (r *StandingRow) GoalDiff() int {
+	return r.GoalsFor - r.GoalsAgainst
+}
+
+// addResult 记入一场比赛
+func (r *StandingRow) addResult(goalsFor, goalsAgainst int) {
+	r.Played++
+	r.GoalsFor += goalsFor
+	r.GoalsAgainst += goalsAgainst
+	switch {
+	case goalsFor > goalsAgainst:
+		r.Won++
+		r.Points += 3
+	case goalsFor == goalsAgainst:
+		r.Drawn++
+		r.Points++
+	default:
+		r.Lost++
+	}
+}
+
+// computeGroupStandings 根据已完赛的小组赛计算积分榜
+// 返回 map[groupName] = 按排名排序的积分榜
+func computeGroupStandings(matches []*Match) map[string][]*StandingRow {
+	return standingsFromMatches(matches, false)
+}
+
+// standingsFromMatches 小组赛中只有完赛的比赛计入积分，includeLive 时进行中的比赛按当前比分计入，
+// 未开赛的球队也会出现在榜上
+func standingsFromMatches(matches []*Match, includeLive bool) map[string][]*StandingRow {
+	rowMap := make(map[string]map[string]*StandingRow)
+	resultMap := make(map[string][]*groupResult)
+	getRow := func(group, teamID, teamName string) *StandingRow {
+		if _, ok := rowMap[group]; !ok {
+			rowMap[group] = make(map[string]*StandingRow)
+		}
+		row, ok := rowMap[group][teamID]
+		if !ok {
+			row = &StandingRow{TeamID: teamID, TeamName: teamName}
+			rowMap[group][teamID] = row
+		}
+		return row
+	}
+
+	for _, match := range matches {
+		if !match.isGroupStage() {
+			continue
+		}
+		host := getRow(match.Group, match.Host.ID, match.Host.Name)
+		guest := getRow(match.Group, match.Guest.ID, match.Guest.Name)
+		if match.Status != StatusFinished && !(includeLive && match.Status == StatusLive) {
+			continue
+		}
+		if match.Score == nil {
+			continue
+		}
+
+		hostScore, guestScore := match.Score.Host, match.Score.Guest
+		host.addResult(hostScore, guestScore)
+		guest.addResult(guestScore, hostScore)
+		resultMap[match.Group] = append(resultMap[match.Group], &groupResult{
+			HostTeamID:  match.Host.ID,
+			GuestTeamID: match.Guest.ID,
+			HostScore:   hostScore,
+			GuestScore:  guestScore,
+		})
+	}
+
+	result := make(map[string][]*StandingRow, len(rowMap))
+	for group, rows := range rowMap {
+		list := make([]*StandingRow, 0, len(rows))
+		for _, row := range rows {
+			list = append(list, row)
+		}
+		rankStandings(list, resultMap[group])
+		result[group] = list
+	}
+	return result
+}
+
+// rankStandings 按 FIFA 小组排名规则排序:
+// 1. 积分 2. 净胜球 3. 进球数
+// 以上相同的球队之间再比较: 4. 相互比赛积分 5. 相互比赛净胜球 6. 相互比赛进球数，
+// 仍相同的部分球队只用它们之间的比赛重复 4~6
+// 7. 公平竞赛分 8. 抽签(这里用 TeamID 保证结果稳定，并标记为 Unresolved)
+func rankStandings(rows []*StandingRow, results []*groupResult) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		return compareOverall(rows[i], rows[j]) < 0
+	})
+
+	for start := 0; start < len(rows); {
+		end := start + 1
+		for end < len(rows) && compareOverall(rows[start], rows[end]) == 0 {
+			end++
+		}
+		if end-start > 1 {
+			rankHeadToHead(rows[start:end], results)
+		}
+		start = end
+	}
+}
+
+// compareOverall 比较积分、净胜球、进球数，a 排名靠前时返回负数
+func compareOverall(a, b *StandingRow) int {
+	if a.Points != b.Points {
+		return b.Points - a.Points
+	}
+	if a.GoalDiff() != b.GoalDiff() {
+		return b.GoalDiff() - a.GoalDiff()
+	}
+	return b.GoalsFor - a.GoalsFor
+}
+
+// rankHeadToHead 对总成绩相同的球队，用相互之间的比赛重新排序；
+// 其中仍相同的部分球队再只用它们之间的比赛排序，直到不能再区分，最后比较公平竞赛分
+func rankHeadToHead(tied []*StandingRow, results []*groupResult) {
+	miniMap := make(map[string]*StandingRow, len(tied))
+	for _, row := range tied {
+		miniMap[row.TeamID] = &StandingRow{TeamID: row.TeamID}
+	}
+	for _, result := range results {
+		host, hostOk := miniMap[result.HostTeamID]
+		guest, guestOk := miniMap[result.GuestTeamID]
+		if !hostOk || !guestOk {
+			continue
+		}
+		host.addResult(result.HostScore, result.GuestScore)
+		guest.addResult(result.GuestScore, result.HostScore)
+	}
+
+	sort.SliceStable(tied, func(i, j int) bool {
+		return compareOverall(miniMap[tied[i].TeamID], miniMap[tied[j].TeamID]) < 0
+	})
+
+	for start := 0; start < len(tied); {
+		end := start + 1
+		for end < len(tied) && compareOverall(miniMap[tied[start].TeamID], miniMap[tied[end].TeamID]) == 0 {
+			end++
+		}
+		switch {
+		case end-start == 1:
+		case end-start < len(tied):
+			rankHeadToHead(tied[start:end], results)
+		default:
+			rankFairPlay(tied[start:end])
+		}
+		start = end
+	}
+}
+
+// rankFairPlay 相互比赛也无法区分时比较公平竞赛分，仍相同的标记为 Unresolved
+func rankFairPlay(tied []*StandingRow) {
+	sort.SliceStable(tied, func(i, j int) bool {
+		if tied[i].FairPlay != tied[j].FairPlay {
+			return tied[i].FairPlay > tied[j].FairPlay
+		}
+		return tied[i].TeamID < tied[j].TeamID
+	})
+	for i := range tied {
+		if (i > 0 && tied[i-1].FairPlay == tied[i].FairPlay) ||
+			(i+1 < len(tied) && tied[i+1].FairPlay == tied[i].FairPlay) {
+			tied[i].Unresolved = true
+		}
+	}
+}
+
+// sortedGroupNames 小组名按字母排序
+func sortedGroupNames(standings map[string][]*StandingRow) []string {
+	names := make([]string, 0, len(standings))
+	for name := range standings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formatStandings 积分榜的 markdown 行，前两名高亮
+func formatStandings(rows []*StandingRow) []string {
+	lines := make([]string, 0, len(rows))
+	for i, row := range rows {
+		line := fmt.Sprintf("%d. %s  %d场 %d胜%d平%d负 %d/%d(%+d) **%d分**",
+			i+1, row.TeamName, row.Played, row.Won, row.Drawn, row.Lost,
+			row.GoalsFor, row.GoalsAgainst, row.GoalDiff(), row.Points)
+		if i < 2 {
+			line = "<font color=\"info\">" + line + "</font>"
+		}
+		lines = append(lines, "> "+line)
+	}
+
+	unresolved := make([]string, 0)
+	for _, row := range rows {
+		if row.Unresolved && row.Played > 0 {
+			unresolved = append(unresolved, row.TeamName)
+		}
+	}
+	if len(unresolved) > 0 {
+		lines = append(lines, fmt.Sprintf("<font color=\"comment\">%s 按积分、净胜球、进球数及相互比赛仍无法区分，"+
+			"数据源没有公平竞赛分，排名暂未确定</font>", strings.Join(unresolved, "、")))
+	}
+	return lines
+}
+
+func groupStarted(rows []*StandingRow) bool {
+	for _, row := range rows {
+		if row.Played > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// notifyStandings 小组赛完赛后推送该组最新积分榜
+func notifyStandings(ctx context.Context, events []*RaceEvent) (err error) {
+	if !conf.PushStandings {
+		return
+	}
+
+	var standings map[string][]*StandingRow
+	pushed := make(map[string]bool)
+	for _, event := range events {
+		match := event.Match
+		if !match.isGroupStage() || pushed[match.Group] || !containsString(event.EventTypes(), EventFullTime) {
+			continue
+		}
+		pushed[match.Group] = true
+
+		if standings == nil {
+			standings = computeGroupStandings(getLatestMatches())
+		}
+		rows, ok := standings[match.Group]
+		if !ok {
+			continue
+		}
+
+		hostScore, guestScore := match.scoreText()
+		lines := []string{fmt.Sprintf("**📊 %s组积分榜更新**", match.Group),
+			fmt.Sprintf("<font color=\"comment\">%s %s : %s %s 完赛</font>",
+				match.Host.Name, hostScore, guestScore, match.Guest.Name)}
+		lines = append(lines, formatStandings(rows)...)
+		pushErr := broadcast(ctx, &Broadcast{
+			Name:  match.Group + "组积分榜",
+			Key:   "standings-" + match.Group,
+			Event: event,
+			Messages: []interface{}{&WeComMarkdownPush{
+				Msgtype:  "markdown",
+				Markdown: &WeComMarkdown{Content: strings.Join(lines, "\n")},
+			}},
+		})
+		if pushErr != nil {
+			err = pushErr
+		}
+	}
+	return
+}
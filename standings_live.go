@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// notifyLiveStandings 小组赛最后一轮进行中，进球改变出线形势(前两名变化)时推送实时积分榜
+func notifyLiveStandings(ctx context.Context, events []*RaceEvent) (err error) {
+	if !conf.PushLiveStandings {
+		return
+	}
+
+	matches := getLatestMatches()
+
+	// 用本次变更前的比分还原出进球前的形势
+	prevMatches := append([]*Match{}, matches...)
+	goalEvents := make(map[string]*RaceEvent)
+	for _, event := range events {
+		match := event.Match
+		if !match.isGroupStage() || match.Status != StatusLive ||
+			!containsString(event.EventTypes(), EventGoal) || !isFinalGroupRound(match, matches) {
+			continue
+		}
+		if _, ok := goalEvents[match.Group]; !ok {
+			goalEvents[match.Group] = event
+		}
+
+		prevHost, prevGuest := event.prevScore()
+		prev := *match
+		prev.Score = &MatchScore{Host: prevHost, Guest: prevGuest}
+		for i, exist := range prevMatches {
+			if exist.ID == match.ID {
+				prevMatches[i] = &prev
+			}
+		}
+	}
+	if len(goalEvents) == 0 {
+		return
+	}
+
+	before := standingsFromMatches(prevMatches, true)
+	after := standingsFromMatches(matches, true)
+	for group, event := range goalEvents {
+		movedIn, movedOut := diffQualified(before[group], after[group])
+		if len(movedIn) == 0 {
+			log.Printf("%s组出线形势未变，跳过实时积分榜。", group)
+			continue
+		}
+
+		pushErr := broadcast(ctx, &Broadcast{
+			Name:     group + "组实时积分榜",
+			Key:      "live-standings-" + group,
+			Event:    event,
+			Messages: []interface{}{makeLiveStandingsPush(group, after[group], movedIn, movedOut)},
+		})
+		if pushErr != nil {
+			err = pushErr
+		}
+	}
+	return
+}
+
+// isFinalGroupRound 主队已踢完另外两场小组赛，即本场是小组最后一轮
+func isFinalGroupRound(match *Match, matches []*Match) bool {
+	played := 0
+	for _, other := range matches {
+		if other.ID == match.ID || other.MatchType != GroupMatchType || other.Status != StatusFinished {
+			continue
+		}
+		if other.hasTeam(match.Host.ID) {
+			played++
+		}
+	}
+	return played >= 2
+}
+
+// diffQualified 比较前两名的变化
+func diffQualified(before, after []*StandingRow) (movedIn, movedOut []*StandingRow) {
+	topTwo := func(rows []*StandingRow) map[string]*StandingRow {
+		result := make(map[string]*StandingRow)
+		for i := 0; i < len(rows) && i < 2; i++ {
+			result[rows[i].TeamID] = rows[i]
+		}
+		return result
+	}
+
+	beforeTop, afterTop := topTwo(before), topTwo(after)
+	for _, row := range after {
+		if _, ok := afterTop[row.TeamID]; ok {
+			if _, was := beforeTop[row.TeamID]; !was {
+				movedIn = append(movedIn, row)
+			}
+		} else if _, was := beforeTop[row.TeamID]; was {
+			movedOut = append(movedOut, row)
+		}
+	}
+	return
+}
+
+func makeLiveStandingsPush(group string, rows, movedIn, movedOut []*StandingRow) (result *WeComMarkdownPush) {
+	lines := []string{fmt.Sprintf("**⚡ %s组实时积分榜(as it stands)**", group)}
+	for _, row := range movedIn {
+		lines = append(lines, fmt.Sprintf("<font color=\"info\">⬆️ %s 升入出线区</font>", row.TeamName))
+	}
+	for _, row := range movedOut {
+		lines = append(lines, fmt.Sprintf("<font color=\"warning\">⬇️ %s 跌出出线区</font>", row.TeamName))
+	}
+	lines = append(lines, formatStandings(rows)...)
+	lines = append(lines, "<font color=\"comment\">进行中的比赛按当前比分计算，仅供参考</font>")
+
+	result = &WeComMarkdownPush{
+		Msgtype:  "markdown",
+		Markdown: &WeComMarkdown{Content: strings.Join(lines, "\n")},
+	}
+	return
+}
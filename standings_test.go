@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestRankStandings 总成绩相同时按相互比赛排名，仍相同的部分球队只用它们之间的比赛再排一次
+func TestRankStandings(t *testing.T) {
+	type result struct{ host, guest, hostScore, guestScore string }
+	cases := []struct {
+		name       string
+		results    []result
+		order      []string
+		unresolved []string
+	}{
+		{
+			// 1、2 同为 3 分、进 1 失 1，2 赢了相互比赛
+			name:    "两队相同",
+			results: []result{{"2", "1", "1", "0"}, {"1", "4", "1", "0"}, {"3", "2", "1", "0"}},
+			order:   []string{"3", "2", "1", "4"},
+		},
+		{
+			// 1、2、3 同为 6 分、净胜 1 球、进 4 球；三队相互比赛中 1 进球最多，
+			// 2、3 仍相同，再只看两队之间的比赛，3 赢了 2
+			name: "三队相同",
+			results: []result{
+				{"3", "2", "1", "0"}, {"1", "3", "2", "1"}, {"2", "1", "2", "1"},
+				{"1", "4", "1", "0"}, {"3", "4", "2", "1"}, {"2", "4", "2", "1"},
+			},
+			order: []string{"1", "3", "2", "4"},
+		},
+		{
+			name:       "相互比赛也无法区分",
+			results:    []result{{"1", "2", "1", "1"}},
+			order:      []string{"1", "2"},
+			unresolved: []string{"1", "2"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			matches := make([]*Match, 0, len(c.results))
+			for i, r := range c.results {
+				match, err := newMatch(testRace(string(rune('1'+i)), "2022-11-21 00:00:00", r.host, r.guest, "3", r.hostScore, r.guestScore))
+				if err != nil {
+					t.Fatal(err)
+				}
+				matches = append(matches, match)
+			}
+
+			rows := computeGroupStandings(matches)["A"]
+			order := make([]string, 0, len(rows))
+			unresolved := make([]string, 0)
+			for _, row := range rows {
+				order = append(order, row.TeamID)
+				if row.Unresolved {
+					unresolved = append(unresolved, row.TeamID)
+				}
+			}
+			if strings.Join(order, ",") != strings.Join(c.order, ",") {
+				t.Errorf("排名为 %v，应为 %v", order, c.order)
+			}
+			if strings.Join(unresolved, ",") != strings.Join(c.unresolved, ",") {
+				t.Errorf("无法区分的球队为 %v，应为 %v", unresolved, c.unresolved)
+			}
+
+			note := strings.Contains(strings.Join(formatStandings(rows), "\n"), "排名暂未确定")
+			if note != (len(c.unresolved) > 0) {
+				t.Errorf("积分榜是否提示排名未确定为 %v", note)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// runState 运行期间的内存状态(部分字段由 StateFile 保存)，各字段仍由所在功能的锁保护，测试中用 newRunState 重建
+type runState struct {
+	// localMap map[teamID] = [matchStatus|HostReamScore|GuestTeamScore]，由 refreshLock 保护
+	localMap map[string]string
+	// latestMatches 最近一次成功拉取并校验后的比赛，供提醒等功能复用，避免额外调用接口
+	latestMatches []*Match
+
+	// pushPaused 暂停期间赛况等推送积压到各渠道(本地状态照常更新)，恢复后补发，错误通知不受影响
+	pushPaused bool
+
+	// fansMap map[teamID] = 配置文件与 http 接口登记的全部球迷，用于 @ 提醒
+	fansMap map[string]*FanConfig
+	// apiFansMap map[teamID] = 通过 http 接口登记的球迷，保存在 FansFile
+	apiFansMap map[string]*FanConfig
+
+	// quietQueue map[channelName] = 免打扰期间积压的比赛，按 TeamID 去重保留最新状态
+	quietQueue map[string][]*Match
+	// quietMessages map[channelName] = 免打扰期间积压的其他消息(积分榜、提醒等)及暂停期间的推送，结束后原样补发
+	quietMessages map[string][]*QuietMessage
+
+	// reminderSent map[teamID-minutes] = 是否已提醒
+	reminderSent map[string]bool
+	// digestStartTime 常驻运行的启动时间，当天启动时已过日报时间则不补发，避免重启后重复推送，由 runDaemon 设置
+	digestStartTime time.Time
+	// digestSent map[channelName] = 最近一次推送日报的日期
+	digestSent map[string]string
+	// scenarioSent map[groupName] = 是否已推送出线形势
+	scenarioSent map[string]bool
+	// malformedReported 已报告过的异常行，避免每次拉取都重复告警
+	malformedReported map[string]bool
+
+	// freshSince 最近一次成功拉取数据的时间，启动时为启动时间，避免刚启动就判定为卡住
+	freshSince time.Time
+	// errReportErr、errReportTime 最近一次错误通知推送失败的原因与时间，成功后清空
+	errReportErr  error
+	errReportTime time.Time
+
+	// quotaDay、quotaUsed 当日(数据源时区)已调用次数，随 StateFile 保存，重启后继续累计
+	quotaDay  string
+	quotaUsed int
+}
+
+func newRunState() *runState {
+	return &runState{
+		fansMap:           make(map[string]*FanConfig),
+		apiFansMap:        make(map[string]*FanConfig),
+		quietQueue:        make(map[string][]*Match),
+		quietMessages:     make(map[string][]*QuietMessage),
+		reminderSent:      make(map[string]bool),
+		digestSent:        make(map[string]string),
+		scenarioSent:      make(map[string]bool),
+		malformedReported: make(map[string]bool),
+	}
+}
+
+// state 当前进程的运行状态
+var state = newRunState()
+
+var stateLock sync.RWMutex
+
+func setLatestMatches(matches []*Match) {
+	stateLock.Lock()
+	defer stateLock.Unlock()
+	state.latestMatches = matches
+}
+
+func getLatestMatches() []*Match {
+	stateLock.RLock()
+	defer stateLock.RUnlock()
+	return state.latestMatches
+}
+
+// StateFile 退出时(及 once 子命令)保存比赛状态与免打扰积压的文件，供下次运行继续比较
+const StateFile = "state.json"
+
+type savedState struct {
+	// Matches 即 localMap
+	Matches map[string]string `json:"matches"`
+	// QuietQueue 即 quietQueue
+	QuietQueue map[string][]*Match `json:"quiet_queue,omitempty"`
+	// QuietMessages 即 quietMessages
+	QuietMessages map[string][]*QuietMessage `json:"quiet_messages,omitempty"`
+	// QuotaDay、QuotaUsed 即 quotaDay、quotaUsed，cron 每次调用 once 都是新进程，需要保存才能估算额度
+	QuotaDay  string `json:"quota_day,omitempty"`
+	QuotaUsed int    `json:"quota_used,omitempty"`
+}
+
+// loadLocalState 读取上次保存的状态，文件不存在时保持未初始化
+func loadLocalState() (err error) {
+	data, err := ioutil.ReadFile(StateFile)
+	if os.IsNotExist(err) {
+		err = nil
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	saved := &savedState{}
+	if err = json.Unmarshal(data, saved); err != nil {
+		return
+	}
+
+	refreshLock.Lock()
+	defer refreshLock.Unlock()
+	quietLock.Lock()
+	defer quietLock.Unlock()
+	state.localMap = saved.Matches
+	for name, queue := range saved.QuietQueue {
+		state.quietQueue[name] = queue
+	}
+	for name, messages := range saved.QuietMessages {
+		state.quietMessages[name] = messages
+	}
+	quotaLock.Lock()
+	state.quotaDay, state.quotaUsed = saved.QuotaDay, saved.QuotaUsed
+	quotaLock.Unlock()
+	log.Printf("已读取保存的状态[%s]：%d 场比赛", StateFile, len(state.localMap))
+	return
+}
+
+// saveLocalState 保存比赛状态与免打扰积压，尚未初始化或 dry-run 时不保存
+func saveLocalState() (err error) {
+	if *dryRun {
+		log.Printf("dry-run，不保存状态[%s]", StateFile)
+		return
+	}
+	refreshLock.Lock()
+	defer refreshLock.Unlock()
+	if state.localMap == nil {
+		return
+	}
+	quietLock.Lock()
+	defer quietLock.Unlock()
+
+	quotaLock.Lock()
+	day, used := state.quotaDay, state.quotaUsed
+	quotaLock.Unlock()
+
+	data, err := json.MarshalIndent(&savedState{Matches: state.localMap, QuietQueue: state.quietQueue,
+		QuietMessages: state.quietMessages, QuotaDay: day, QuotaUsed: used}, "", "  ")
+	if err != nil {
+		return
+	}
+	err = ioutil.WriteFile(StateFile, data, 0644)
+	return
+}
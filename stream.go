@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// StreamHeartbeat 实时流的心跳间隔
+const StreamHeartbeat = 15 * time.Second
+
+// streamHub 实时流订阅者
+type streamHub struct {
+	lock    sync.Mutex
+	clients map[chan []byte]bool
+	// done http 服务关闭时关闭，通知长连接退出
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+var hub = &streamHub{clients: make(map[chan []byte]bool), done: make(chan struct{})}
+
+func (h *streamHub) subscribe() chan []byte {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	ch := make(chan []byte, 64)
+	h.clients[ch] = true
+	return ch
+}
+
+func (h *streamHub) unsubscribe(ch chan []byte) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	delete(h.clients, ch)
+}
+
+// shutdown 通知所有实时流连接退出
+func (h *streamHub) shutdown() {
+	h.closeOnce.Do(func() {
+		close(h.done)
+	})
+}
+
+// broadcast 慢的订阅者直接丢弃消息，避免阻塞主流程
+func (h *streamHub) broadcast(msg []byte) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for ch := range h.clients {
+		select {
+		case ch <- msg:
+		default:
+			log.Printf("实时流订阅者积压过多，丢弃一条消息。")
+		}
+	}
+}
+
+// publishEvents 把检测到的变更推给所有实时流订阅者
+func publishEvents(events []*RaceEvent) {
+	for _, event := range events {
+		msg, _ := json.Marshal(makeEventPayload(event))
+		hub.broadcast(msg)
+	}
+}
+
+// snapshotPayloads 连接建立时回放当前全部比赛
+func snapshotPayloads() (result [][]byte) {
+	now := clock.Now()
+	for _, match := range getLatestMatches() {
+		msg, _ := json.Marshal(&EventPayload{Type: PayloadSnapshot, Time: now, Match: match})
+		result = append(result, msg)
+	}
+	return
+}
+
+func heartbeatPayload() []byte {
+	msg, _ := json.Marshal(&EventPayload{Type: PayloadHeartbeat, Time: clock.Now()})
+	return msg
+}
+
+// handleSSE GET /api/stream 以 Server-Sent Events 推送比赛事件
+func handleSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ch := hub.subscribe()
+	defer hub.unsubscribe(ch)
+
+	write := func(eventName string, msg []byte) bool {
+		_, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventName, msg)
+		flusher.Flush()
+		return err == nil
+	}
+
+	for _, msg := range snapshotPayloads() {
+		if !write(PayloadSnapshot, msg) {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(StreamHeartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-hub.done:
+			return
+		case msg := <-ch:
+			if !write(PayloadEvent, msg) {
+				return
+			}
+		case <-ticker.C:
+			if !write(PayloadHeartbeat, heartbeatPayload()) {
+				return
+			}
+		}
+	}
+}
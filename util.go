@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
@@ -11,11 +12,12 @@ func GoWithRecovery(f func()) {
 	go func() {
 		defer func() {
 			if e := recover(); e != nil {
+				panicsTotal.Inc()
 				stack := Stack(3)
 				outErr := errors.New(fmt.Sprintf("recover stack: %s, err: %s", stack, e))
 				log.Printf(outErr.Error())
 
-				httpPostJson(ErrReportApi, getErrStr(outErr))
+				reportErr(context.Background(), getErrStr(outErr))
 			}
 		}()
 		f()
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// PushRetryTimes 推送失败后的最大尝试次数(含首次)
+const PushRetryTimes = 5
+
+// PushRetryBaseDelay 首次重试前的等待时间，之后每次翻倍
+const PushRetryBaseDelay = time.Second
+
+// PushRetryMaxDelay 单次重试等待时间上限
+const PushRetryMaxDelay = 30 * time.Second
+
+var (
+	jitterLock sync.Mutex
+	// jitterRand 退避抖动使用的随机数，rand.Rand 不是并发安全的，使用时加锁
+	jitterRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// weComRetryableCodes 可以重试的企业微信 errcode，其余(如 key 无效、消息格式错误)重试也不会成功
+var weComRetryableCodes = map[int]bool{
+	-1:    true, // 系统繁忙
+	45009: true, // 接口调用超过限制
+}
+
+// permanentError 重试也不会成功的推送错误，如 4xx 响应、不可重试的 errcode，直接写入死信
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// isPermanent err 是否为不需要重试的推送错误
+func isPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// ErrReportChannel 错误通知在指标中的渠道名
+const ErrReportChannel = "error_report"
+
+// PausedMessageName 暂停期间积压的消息在日志中的名称
+const PausedMessageName = "暂停期间的推送"
+
+// postWithRetry 带指数退避的推送，全部失败后写入死信文件。
+// 暂停推送时同免打扰一样积压到渠道，恢复后由 flushQuietQueues 补发
+func postWithRetry(ctx context.Context, url string, msg []byte) (err error) {
+	if isPushPaused() {
+		channel := conf.channelByURL(url)
+		if channel == nil {
+			log.Printf("推送已暂停，未知渠道的消息丢弃[%s]", string(msg))
+			return
+		}
+		enqueueQuietMessage(channel, &QuietMessage{Name: PausedMessageName, Msgs: []json.RawMessage{msg}})
+		log.Printf("推送已暂停，积压消息[%s]", channel.Name)
+		return
+	}
+	err = postAndRecord(ctx, url, channelNameByURL(url), msg)
+	return
+}
+
+// reportErr 推送错误通知到 ErrReportApi，不受暂停推送影响，推送结果用于 /readyz。
+// ErrReportApi 可能与渠道地址相同，所以不能按地址区分错误通知
+func reportErr(ctx context.Context, msg []byte) (err error) {
+	err = postAndRecord(ctx, ErrReportApi, ErrReportChannel, msg)
+	markErrReport(clock.Now(), err)
+	return
+}
+
+// postAndRecord 推送并记录指标，全部失败后写入死信文件
+func postAndRecord(ctx context.Context, url, channel string, msg []byte) (err error) {
+	start := time.Now()
+	err = postWithBackoff(ctx, url, msg)
+	recordNotify(channel, time.Since(start), err)
+	if err == nil {
+		return
+	}
+
+	if dlErr := saveDeadLetter(url, msg, err); dlErr != nil {
+		log.Printf("写入死信失败, err[%s], msg[%s]", dlErr.Error(), string(msg))
+	}
+	return
+}
+
+// postWithBackoff 按 PushRetryTimes 重试推送，不落死信。
+// 只重试网络异常、5xx、429 和企业微信限流，其他错误重试也不会成功，直接返回，避免持锁空等
+func postWithBackoff(ctx context.Context, url string, msg []byte) (err error) {
+	for i := 0; i < PushRetryTimes; i++ {
+		if i > 0 {
+			delay := backoffDelay(i)
+			log.Printf("推送失败, %s 后进行第 %d 次重试, err[%s]", delay, i, err.Error())
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		err = httpPostJson(ctx, url, msg)
+		if err == nil || isPermanent(err) {
+			return
+		}
+	}
+	return
+}
+
+// backoffDelay 第 attempt 次重试的等待时间，在 [d/2, d) 之间随机抖动
+func backoffDelay(attempt int) time.Duration {
+	d := PushRetryBaseDelay << uint(attempt-1)
+	if d <= 0 || d > PushRetryMaxDelay {
+		d = PushRetryMaxDelay
+	}
+	half := d / 2
+	jitterLock.Lock()
+	defer jitterLock.Unlock()
+	return half + time.Duration(jitterRand.Int63n(int64(half)+1))
+}
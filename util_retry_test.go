@@ -0,0 +1,22 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+// TestPostPermanentError 不可重试的 errcode 只推送一次，直接写入死信
+func TestPostPermanentError(t *testing.T) {
+	h := newHarness(t)
+	h.wecom.failNext("/robot", 93000)
+
+	if err := postWithRetry(context.Background(), RobotApi, []byte(`{"msgtype":"text","text":{"content":"hi"}}`)); !isPermanent(err) {
+		t.Fatalf("err[%v]，期望不可重试的错误", err)
+	}
+	if messages := h.wecom.take(); len(messages) != 1 {
+		t.Fatalf("推送了 %d 次，期望只推送一次", len(messages))
+	}
+	if letters, err := loadDeadLetters(); err != nil || len(letters) != 1 {
+		t.Fatalf("死信 %d 条, err[%v]，期望 1 条", len(letters), err)
+	}
+}
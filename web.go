@@ -0,0 +1,48 @@
+package main
+
+import (
+	"embed"
+	"net/http"
+	"sort"
+)
+
+//go:embed web
+var webFS embed.FS
+
+// handleIndex GET / 实时比分大屏页面
+func handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	page, err := webFS.ReadFile("web/index.html")
+	if err != nil {
+		writeJsonErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(page)
+}
+
+// handleStandings GET /api/standings 全部小组积分榜
+func handleStandings(w http.ResponseWriter, r *http.Request) {
+	standings := computeGroupStandings(getLatestMatches())
+	resp := make([]*standingsResp, 0, len(standings))
+	for _, group := range sortedGroupNames(standings) {
+		resp = append(resp, makeStandingsResp(group, standings[group]))
+	}
+	sort.SliceStable(resp, func(i, j int) bool { return resp[i].Group < resp[j].Group })
+	writeJsonWithETag(w, r, resp)
+}
+
+// handleBracket GET /api/bracket 对阵图 json；GET /api/bracket.svg 对阵图矢量图
+func handleBracket(w http.ResponseWriter, r *http.Request) {
+	bracket := buildBracket(getLatestMatches())
+	if r.URL.Path == "/api/bracket.svg" {
+		w.Header().Set("Content-Type", "image/svg+xml; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache")
+		_, _ = w.Write(bracket.RenderSVG())
+		return
+	}
+	writeJsonWithETag(w, r, bracket)
+}
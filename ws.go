@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsWriteWait 单条消息的写超时
+	wsWriteWait = 10 * time.Second
+	// wsPongWait 客户端需在该时间内响应服务端随心跳发送的 ping，否则断开
+	wsPongWait = 2 * StreamHeartbeat
+	// wsMaxMessage 客户端只需要发送控制帧，限制消息大小
+	wsMaxMessage = 4096
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+}
+
+// handleWebSocket GET /api/ws 以 WebSocket 推送比赛事件，消息与 SSE 相同
+func handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	// 握手失败时 Upgrade 已经返回了错误响应
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	ch := hub.subscribe()
+	defer hub.unsubscribe(ch)
+
+	conn.SetReadLimit(wsMaxMessage)
+	_ = conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	// 读取客户端的消息，ping/close 由库处理，读超时或连接断开时退出
+	closed := make(chan struct{})
+	GoWithRecovery(func() {
+		defer close(closed)
+		for {
+			if _, _, readErr := conn.NextReader(); readErr != nil {
+				return
+			}
+		}
+	})
+
+	write := func(msg []byte) bool {
+		_ = conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		return conn.WriteMessage(websocket.TextMessage, msg) == nil
+	}
+
+	for _, msg := range snapshotPayloads() {
+		if !write(msg) {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(StreamHeartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case <-hub.done:
+			// 接管后的连接不受 http.Server.Shutdown 管理，需要自己关闭
+			_ = conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, "服务关闭"), time.Now().Add(wsWriteWait))
+			return
+		case msg := <-ch:
+			if !write(msg) {
+				return
+			}
+		case <-ticker.C:
+			if !write(heartbeatPayload()) {
+				return
+			}
+			if conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)) != nil {
+				return
+			}
+		}
+	}
+}